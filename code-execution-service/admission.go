@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/IMMZEK/AggieCode/code-execution-service/metrics"
+)
+
+// Defaults for AdmissionControl, overridable via RATE_PER_MIN, BURST, QUEUE_DEPTH, and
+// MAX_WAIT_MS.
+const (
+	DefaultRatePerMinute = 60
+	DefaultBurst         = 10
+	DefaultQueueDepth    = 50
+	DefaultMaxWait       = 5 * time.Second
+)
+
+// ErrQueueFull and ErrMaxWaitExceeded are returned by AdmissionControl.Admit, distinct from
+// the executor's own "limit_exceeded" error which reflects the global concurrency cap rather
+// than a single identity hogging it.
+var (
+	ErrQueueFull       = errors.New("admission queue is full for this client")
+	ErrMaxWaitExceeded = errors.New("exceeded maximum wait time for admission")
+)
+
+// AdmissionControl enforces a per-identity token-bucket rate limit plus a bounded FIFO wait
+// queue in front of executeHandler, so one noisy client can't starve everyone else out of
+// the shared concurrency budget the executor's semaphore guards.
+type AdmissionControl struct {
+	ratePerMin int
+	burst      int
+	queueDepth int
+	maxWait    time.Duration
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	queued   map[string]int // current waiters per identity, bounded by queueDepth
+
+	// trustedProxies lists the direct-peer networks allowed to supply a client IP via
+	// X-Forwarded-For. Anyone else's forwarded header is ignored - otherwise a direct client could
+	// simply set its own X-Forwarded-For and mint a fresh identity per request, dodging the very
+	// rate limiting/fair-queueing this struct exists to provide. Mirrors
+	// packages/ratelimit.go's RateLimiter.trustedProxies.
+	trustedProxies []*net.IPNet
+}
+
+// NewAdmissionControl builds an AdmissionControl with the given per-identity limits.
+func NewAdmissionControl(ratePerMin, burst, queueDepth int, maxWait time.Duration) *AdmissionControl {
+	return &AdmissionControl{
+		ratePerMin: ratePerMin,
+		burst:      burst,
+		queueDepth: queueDepth,
+		maxWait:    maxWait,
+		limiters:   make(map[string]*rate.Limiter),
+		queued:     make(map[string]int),
+	}
+}
+
+// admissionControlFromEnv builds an AdmissionControl from RATE_PER_MIN/BURST/QUEUE_DEPTH/
+// MAX_WAIT_MS/TRUSTED_PROXIES, falling back to the Default* constants for anything unset or
+// invalid.
+func admissionControlFromEnv() *AdmissionControl {
+	ac := NewAdmissionControl(
+		getIntEnv("RATE_PER_MIN", DefaultRatePerMinute),
+		getIntEnv("BURST", DefaultBurst),
+		getIntEnv("QUEUE_DEPTH", DefaultQueueDepth),
+		getDurationMsEnv("MAX_WAIT_MS", DefaultMaxWait),
+	)
+	ac.SetTrustedProxies(trustedProxiesFromEnv())
+	return ac
+}
+
+// SetTrustedProxies configures which direct-peer networks may supply a client IP via
+// X-Forwarded-For instead of RemoteAddr.
+func (a *AdmissionControl) SetTrustedProxies(proxies []*net.IPNet) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.trustedProxies = proxies
+}
+
+func (a *AdmissionControl) isTrustedProxy(ip net.IP) bool {
+	for _, proxy := range a.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedProxiesFromEnv parses TRUSTED_PROXIES, a comma-separated list of CIDRs (e.g.
+// "10.0.0.0/8,192.168.1.0/24"), into the networks identityFor trusts to supply a client IP via
+// X-Forwarded-For. Entries that fail to parse are skipped rather
+// than rejected outright, so one typo doesn't take the service down.
+func trustedProxiesFromEnv() []*net.IPNet {
+	raw := os.Getenv("TRUSTED_PROXIES")
+	if raw == "" {
+		return nil
+	}
+
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			continue
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies
+}
+
+func (a *AdmissionControl) limiterFor(identity string) *rate.Limiter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limiter, ok := a.limiters[identity]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(float64(a.ratePerMin)/60), a.burst)
+		a.limiters[identity] = limiter
+	}
+	return limiter
+}
+
+// Admit blocks until identity's token bucket yields a token, or returns ErrQueueFull if the
+// bounded wait queue for identity is already at capacity, or ErrMaxWaitExceeded if a token
+// doesn't free up within maxWait. The returned duration is how long the caller waited, for
+// callers to surface as queue_wait_ms.
+func (a *AdmissionControl) Admit(ctx context.Context, identity string) (time.Duration, error) {
+	limiter := a.limiterFor(identity)
+	if limiter.Allow() {
+		return 0, nil
+	}
+
+	a.mu.Lock()
+	if a.queued[identity] >= a.queueDepth {
+		a.mu.Unlock()
+		return 0, ErrQueueFull
+	}
+	a.queued[identity]++
+	metrics.QueueDepth.Inc()
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		a.queued[identity]--
+		a.mu.Unlock()
+		metrics.QueueDepth.Dec()
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, a.maxWait)
+	defer cancel()
+
+	start := time.Now()
+	if err := limiter.Wait(waitCtx); err != nil {
+		waited := time.Since(start)
+		// Wait fails either when waitCtx expires or, up front, when the token it would reserve
+		// lies past waitCtx's deadline; unless the caller's own ctx ended, both mean maxWait
+		// would be exceeded.
+		if ctx.Err() != nil {
+			return waited, ctx.Err()
+		}
+		return waited, ErrMaxWaitExceeded
+	}
+	return time.Since(start), nil
+}
+
+// identityFor extracts the caller's identity: the bearer API key if present, else the client IP.
+// X-Forwarded-For is only trusted to supply that IP when RemoteAddr itself is a configured
+// trusted proxy (see SetTrustedProxies) - otherwise any direct client could set its own
+// X-Forwarded-For and mint a fresh identity per request, dodging admission control entirely.
+func (a *AdmissionControl) identityFor(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return "key:" + strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if peer := net.ParseIP(host); peer != nil && a.isTrustedProxy(peer) {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			if client := strings.TrimSpace(strings.Split(xff, ",")[0]); client != "" {
+				return "ip:" + client
+			}
+		}
+	}
+	return "ip:" + host
+}
+
+func getIntEnv(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+func getDurationMsEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return def
+	}
+	return time.Duration(n) * time.Millisecond
+}
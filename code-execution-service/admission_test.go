@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdmissionControl_AllowsWithinBurst(t *testing.T) {
+	ac := NewAdmissionControl(60, 3, 10, time.Second)
+
+	for i := 0; i < 3; i++ {
+		if _, err := ac.Admit(context.Background(), "client-a"); err != nil {
+			t.Fatalf("request %d: expected to be admitted immediately, got: %v", i, err)
+		}
+	}
+}
+
+func TestAdmissionControl_QueueFullRejectsImmediately(t *testing.T) {
+	// burst of 1 and a queue depth of 0 means the second request has nowhere to wait.
+	ac := NewAdmissionControl(60, 1, 0, time.Second)
+
+	if _, err := ac.Admit(context.Background(), "client-a"); err != nil {
+		t.Fatalf("first request: expected to be admitted, got: %v", err)
+	}
+	if _, err := ac.Admit(context.Background(), "client-a"); err != ErrQueueFull {
+		t.Errorf("second request: expected ErrQueueFull, got: %v", err)
+	}
+}
+
+func TestAdmissionControl_PerIdentityIsolation(t *testing.T) {
+	ac := NewAdmissionControl(60, 1, 0, time.Second)
+
+	if _, err := ac.Admit(context.Background(), "client-a"); err != nil {
+		t.Fatalf("client-a: expected to be admitted, got: %v", err)
+	}
+	// client-b has its own bucket, so client-a exhausting its burst shouldn't affect it.
+	if _, err := ac.Admit(context.Background(), "client-b"); err != nil {
+		t.Errorf("client-b: expected to be admitted despite client-a's burst, got: %v", err)
+	}
+}
+
+func TestAdmissionControl_FairSchedulingAcrossConcurrentClients(t *testing.T) {
+	// 600/min is one token every 100ms, so with a burst of 1 the last of the 5 clients waits
+	// about 400ms; maxWait has to exceed that for every client to be admitted.
+	ac := NewAdmissionControl(600, 1, 10, time.Second)
+
+	const clients = 5
+	waits := make([]time.Duration, clients)
+	errs := make([]error, clients)
+
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			waits[i], errs[i] = ac.Admit(context.Background(), "shared-client")
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("client %d: unexpected admission error: %v", i, err)
+		}
+		if waits[i] > ac.maxWait {
+			t.Errorf("client %d: waited %v, longer than MAX_WAIT_MS=%v", i, waits[i], ac.maxWait)
+		}
+	}
+}
+
+func TestIdentityFor(t *testing.T) {
+	_, trustedNet, _ := net.ParseCIDR("192.0.2.0/24")
+
+	tests := []struct {
+		name string
+		ac   *AdmissionControl
+		req  func() *http.Request
+		want string
+	}{
+		{
+			name: "bearer token takes priority",
+			ac:   NewAdmissionControl(60, 3, 10, time.Second),
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/api/execute", nil)
+				r.Header.Set("Authorization", "Bearer secret-key")
+				r.Header.Set("X-Forwarded-For", "203.0.113.5")
+				return r
+			},
+			want: "key:secret-key",
+		},
+		{
+			// httptest.NewRequest defaults RemoteAddr to 192.0.2.1, which isn't in
+			// trustedProxies here, so X-Forwarded-For must be ignored in favor of RemoteAddr -
+			// otherwise any direct client could mint a fresh identity per request just by
+			// setting the header itself.
+			name: "ignored unless RemoteAddr is a trusted proxy",
+			ac:   NewAdmissionControl(60, 3, 10, time.Second),
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/api/execute", nil)
+				r.Header.Set("X-Forwarded-For", "203.0.113.5, 10.0.0.1")
+				return r
+			},
+			want: "ip:192.0.2.1",
+		},
+		{
+			name: "honored when RemoteAddr is a trusted proxy",
+			ac: func() *AdmissionControl {
+				ac := NewAdmissionControl(60, 3, 10, time.Second)
+				ac.SetTrustedProxies([]*net.IPNet{trustedNet})
+				return ac
+			}(),
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/api/execute", nil)
+				r.Header.Set("X-Forwarded-For", "203.0.113.5, 10.0.0.1")
+				return r
+			},
+			want: "ip:203.0.113.5",
+		},
+		{
+			name: "falls back to RemoteAddr",
+			ac:   NewAdmissionControl(60, 3, 10, time.Second),
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/api/execute", nil)
+				r.RemoteAddr = "198.51.100.7:54321"
+				return r
+			},
+			want: "ip:198.51.100.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ac.identityFor(tt.req()); got != tt.want {
+				t.Errorf("identityFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Command execution-service is the deployment entrypoint for packages/pkg's ExecutionService:
+// the AST-sanitizing, rate-limited, streaming code runner built across the "chunk2" series. It
+// lives on its own port, separate from the top-level code-execution-service binary (whose
+// /api/execute* routes are already served by the docker-exec-driven executor package) rather than
+// sharing that ServeMux, since the two packages define distinct request/response shapes and
+// neither should silently shadow the other's routes.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/IMMZEK/AggieCode/code-execution-service/packages"
+)
+
+// writeTimeout mirrors the http.Server's WriteTimeout so TimeoutMiddleware can preempt it with a
+// structured response instead of letting net/http sever the connection mid-write.
+const writeTimeout = 35 * time.Second
+
+func main() {
+	service := pkg.NewExecutionService()
+	defer service.RateLimiter.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/execute", pkg.TimeoutMiddleware(service.RateLimiter.Limit(http.HandlerFunc(service.HandleExecute)), writeTimeout, 0))
+	mux.HandleFunc("/api/execute/stream", service.HandleExecuteStream)
+
+	addr := os.Getenv("EXECUTION_SERVICE_ADDR")
+	if addr == "" {
+		addr = ":8082"
+	}
+
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	log.Printf("execution-service listening on %s", addr)
+	log.Fatal(server.ListenAndServe())
+}
@@ -0,0 +1,113 @@
+// Command executor-sidecar is the minimal HTTP server each language's Docker image embeds so
+// ExecutionService's lang.HTTPExecutor can reach a warm, long-lived runtime instead of shelling
+// out to `docker exec` per request. It knows nothing about any particular language: the image
+// supplies the actual interpreter/compiler invocation via the RUNNER_CMD environment variable
+// (e.g. "python3 -c" for the Python image, "node -e" for the JS image), and this just wires up
+// stdin/stdout/stderr, a per-request timeout, and the memory ulimit wrapper around it.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ulimitWrapper mirrors packages/lang/exec.go's wrapper: it takes the memory ceiling in KB as $1
+// and the real command as the rest of argv, so the submitted code is passed along via "$@"
+// rather than interpolated into the script string.
+const ulimitWrapper = `ulimit -v "$1"; shift; exec "$@"`
+
+type runRequest struct {
+	Code          string   `json:"code"`
+	Stdin         string   `json:"stdin,omitempty"`
+	Args          []string `json:"args,omitempty"`
+	TimeoutMs     int64    `json:"timeout_ms,omitempty"`
+	MemoryLimitMB int      `json:"memory_limit_mb,omitempty"`
+}
+
+type runResponse struct {
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	ErrorType string `json:"error_type,omitempty"`
+}
+
+const (
+	errorTypeTimeout = "timeout"
+	errorTypeOOM     = "oom"
+	errorTypeRuntime = "runtime"
+	oomExitCode      = 137
+	defaultTimeout   = 10 * time.Second
+)
+
+func handleRun(runnerCmd []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req runRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		timeout := defaultTimeout
+		if req.TimeoutMs > 0 {
+			timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		argv := append(append([]string{}, runnerCmd...), req.Code)
+		argv = append(argv, req.Args...)
+
+		if req.MemoryLimitMB > 0 {
+			wrapped := append([]string{"sh", "-c", ulimitWrapper, "sh", strconv.Itoa(req.MemoryLimitMB * 1024)}, argv...)
+			argv = wrapped
+		}
+
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		cmd.Stdin = strings.NewReader(req.Stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		resp := runResponse{}
+		switch err := cmd.Run(); {
+		case ctx.Err() == context.DeadlineExceeded:
+			resp.ErrorType = errorTypeTimeout
+		case err == nil:
+			// clean exit
+		default:
+			if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == oomExitCode {
+				resp.ErrorType = errorTypeOOM
+			} else {
+				resp.ErrorType = errorTypeRuntime
+			}
+		}
+		resp.Stdout = stdout.String()
+		resp.Stderr = stderr.String()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func main() {
+	runnerCmd := strings.Fields(os.Getenv("RUNNER_CMD"))
+	if len(runnerCmd) == 0 {
+		log.Fatal("RUNNER_CMD must be set, e.g. \"python3 -c\"")
+	}
+
+	addr := os.Getenv("EXECUTOR_SIDECAR_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+
+	http.HandleFunc("/run", handleRun(runnerCmd))
+	log.Printf("executor-sidecar listening on %s, runner %q", addr, strings.Join(runnerCmd, " "))
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
@@ -0,0 +1,290 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// compileArtifacts lists the files a LanguageCompilers language's compile step leaves in
+// TempDir that the run step (and the compile cache) need to carry forward.
+var compileArtifacts = map[string][]string{
+	"cpp":  {"a.out"},
+	"java": {"Main.class"},
+	"go":   {"app"},
+}
+
+// buildCompileCommand returns the command that compiles codeFile into the artifacts listed in
+// compileArtifacts, for a LanguageCompilers language.
+func buildCompileCommand(codeFile, language string) []string {
+	switch language {
+	case "cpp":
+		return []string{"/bin/sh", "-c", fmt.Sprintf("g++ -O2 -o a.out %s", codeFile)}
+	case "java":
+		return []string{"/bin/sh", "-c", fmt.Sprintf("javac %s", codeFile)}
+	case "go":
+		return []string{"/bin/sh", "-c", fmt.Sprintf("go build -o app %s", codeFile)}
+	default:
+		return nil
+	}
+}
+
+// buildRunCommand returns the command that runs a LanguageCompilers language's already-compiled
+// artifacts, redirecting stdinFile in if one was written.
+func buildRunCommand(stdinFile, language string) []string {
+	switch language {
+	case "cpp":
+		if stdinFile != "" {
+			return []string{"/bin/sh", "-c", fmt.Sprintf("./a.out < %s", stdinFile)}
+		}
+		return []string{"./a.out"}
+	case "java":
+		if stdinFile != "" {
+			return []string{"/bin/sh", "-c", fmt.Sprintf("java -cp . Main < %s", stdinFile)}
+		}
+		return []string{"java", "-cp", ".", "Main"}
+	case "go":
+		if stdinFile != "" {
+			return []string{"/bin/sh", "-c", fmt.Sprintf("./app < %s", stdinFile)}
+		}
+		return []string{"./app"}
+	default:
+		return nil
+	}
+}
+
+// compileCacheKey identifies a compiled artifact by the inputs that determine it: the source
+// code, the language, and the image it was compiled in (image tags double as the compiler
+// version here, since a new compiler ships as a new image).
+func compileCacheKey(language, code, imageName string) string {
+	h := sha256.New()
+	h.Write([]byte(language))
+	h.Write([]byte{0})
+	h.Write([]byte(imageName))
+	h.Write([]byte{0})
+	h.Write([]byte(code))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// compileCache is an on-disk cache of compiled artifacts keyed by compileCacheKey, so
+// resubmitting the same code against the same language/image skips the compile container
+// entirely. A single mutex serializes access, matching the simple locking CodeExecutor itself
+// uses for its semaphore rather than per-key locking.
+type compileCache struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// newCompileCache creates (if needed) and returns a compileCache rooted at dir, defaulting to a
+// directory under os.TempDir() when dir is empty.
+func newCompileCache(dir string) (*compileCache, error) {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "aggiecode-compile-cache")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create compile cache directory %q: %w", dir, err)
+	}
+	return &compileCache{dir: dir}, nil
+}
+
+func (c *compileCache) entryDir(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+// Get copies the cached artifacts for key into tempDir, reporting whether a cache entry existed.
+// A missing or unreadable artifact is treated as a cache miss rather than an error, so a
+// partially-written or corrupted entry just falls back to recompiling.
+func (c *compileCache) Get(key, tempDir string, artifacts []string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	src := c.entryDir(key)
+	if _, err := os.Stat(src); err != nil {
+		return false
+	}
+	for _, name := range artifacts {
+		data, err := ioutil.ReadFile(filepath.Join(src, name))
+		if err != nil {
+			return false
+		}
+		if err := ioutil.WriteFile(filepath.Join(tempDir, name), data, 0755); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Put copies the compiled artifacts for key out of tempDir and into the cache for future hits.
+func (c *compileCache) Put(key, tempDir string, artifacts []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dest := c.entryDir(key)
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	for _, name := range artifacts {
+		data, err := ioutil.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dest, name), data, 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeCompiled runs a LanguageCompilers language as two container runs instead of Execute's
+// usual single run: a compile step (read-write /code, extra memory/PID headroom for the
+// compiler's own child processes) that populates compileArtifacts, followed by a run step
+// (read-only /code, the language's normal profile limits, stdin attached). A compile-cache hit
+// skips straight to the run step. CompileTimeMs/RunTimeMs report each step's wall-clock time
+// separately; a non-zero compile exit code is reported as ExecutionError{Type: "compilation"}
+// directly, rather than guessed from stderr content the way the single-container path used to.
+func (e *CodeExecutor) executeCompiled(ctx context.Context, req ExecutionRequest, startTime time.Time, tempDir, filename, stdinFile, imageName string, profile LanguageProfile) (ExecutionResult, error) {
+	result := ExecutionResult{}
+	artifacts := compileArtifacts[req.Language]
+	key := compileCacheKey(req.Language, req.Code, imageName)
+
+	if !e.compileCache.Get(key, tempDir, artifacts) {
+		compileResult, err := e.runCompileStep(ctx, req.Language, filename, tempDir, imageName, profile)
+		result.CompileTimeMs = compileResult.timeMs
+		if err != nil {
+			return result, err
+		}
+		if compileResult.exitCode != 0 {
+			result.Stderr = compileResult.stderr
+			return result, ExecutionError{
+				Type:    "compilation",
+				Message: fmt.Sprintf("compilation failed with exit code %d", compileResult.exitCode),
+			}
+		}
+		if err := e.compileCache.Put(key, tempDir, artifacts); err != nil {
+			// A cache-write failure doesn't invalidate a successful compile; the run step below
+			// still has the artifacts in tempDir, it just won't be cached for next time.
+			result.Stderr = compileResult.stderr
+		}
+	}
+
+	runStart := time.Now()
+	runSpec := ContainerSpec{
+		ImageName:    imageName,
+		Cmd:          buildRunCommand(filepath.Base(stdinFile), req.Language),
+		TempDir:      tempDir,
+		Memory:       profile.Memory,
+		MemorySwap:   profile.MemorySwap,
+		NanoCPUs:     profile.NanoCPUs,
+		PidsLimit:    profile.PidsLimit,
+		TmpfsSize:    profile.TmpfsSize,
+		Ulimits:      profile.Ulimits,
+		ReadOnlyCode: true,
+	}
+
+	handle, err := e.runtimeBackend.CreateAndStart(ctx, runSpec)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return result, ExecutionError{Type: "timeout", Message: fmt.Sprintf("container creation timed out after %v", req.Timeout)}
+		}
+		return result, fmt.Errorf("container execution failed: %w", err)
+	}
+	defer e.runtimeBackend.Cleanup(context.Background(), handle)
+
+	exitCode, oomKilled, err := e.runtimeBackend.Wait(ctx, handle)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return result, ExecutionError{Type: "timeout", Message: fmt.Sprintf("execution timed out after %v", req.Timeout)}
+		}
+		return result, fmt.Errorf("error waiting for container: %w", err)
+	}
+	if oomKilled {
+		return result, ExecutionError{Type: "memory_limit", Message: "execution exceeded memory limit"}
+	}
+
+	stdout, stderr, err := e.runtimeBackend.Logs(context.Background(), handle)
+	if err != nil {
+		return result, fmt.Errorf("failed to get container logs: %w", err)
+	}
+
+	result.Stdout = stdout
+	result.Stderr = stderr
+	result.RunTimeMs = time.Since(runStart).Milliseconds()
+	result.ExecTimeMs = time.Since(startTime).Milliseconds()
+
+	if exitCode != 0 {
+		result.Error = fmt.Sprintf("Process exited with code %d", exitCode)
+	}
+
+	return result, nil
+}
+
+// compileStepResult is runCompileStep's internal result shape; only executeCompiled reads it.
+type compileStepResult struct {
+	exitCode int64
+	stderr   string
+	timeMs   int64
+}
+
+// runCompileStep runs language's compile command in its own container, with a separate
+// CompileTimeout and double the run profile's memory/PID headroom (a compiler spawns its own
+// child processes and is heavier than the program it produces).
+func (e *CodeExecutor) runCompileStep(ctx context.Context, language, filename, tempDir, imageName string, profile LanguageProfile) (compileStepResult, error) {
+	start := time.Now()
+
+	compileCtx, cancel := context.WithTimeout(ctx, profile.CompileTimeout)
+	defer cancel()
+
+	spec := ContainerSpec{
+		ImageName:  imageName,
+		Cmd:        buildCompileCommand(filepath.Base(filename), language),
+		TempDir:    tempDir,
+		Memory:     profile.Memory * 2,
+		MemorySwap: profile.MemorySwap * 2,
+		NanoCPUs:   profile.NanoCPUs,
+		PidsLimit:  profile.PidsLimit * 2,
+		TmpfsSize:  profile.TmpfsSize,
+		Ulimits:    profile.Ulimits,
+	}
+
+	handle, err := e.runtimeBackend.CreateAndStart(compileCtx, spec)
+	if err != nil {
+		if compileCtx.Err() == context.DeadlineExceeded {
+			return compileStepResult{timeMs: time.Since(start).Milliseconds()}, ExecutionError{
+				Type:    "timeout",
+				Message: fmt.Sprintf("compilation timed out after %v", profile.CompileTimeout),
+			}
+		}
+		return compileStepResult{timeMs: time.Since(start).Milliseconds()}, fmt.Errorf("compile container failed: %w", err)
+	}
+	defer e.runtimeBackend.Cleanup(context.Background(), handle)
+
+	exitCode, oomKilled, err := e.runtimeBackend.Wait(compileCtx, handle)
+	if err != nil {
+		if compileCtx.Err() == context.DeadlineExceeded {
+			return compileStepResult{timeMs: time.Since(start).Milliseconds()}, ExecutionError{
+				Type:    "timeout",
+				Message: fmt.Sprintf("compilation timed out after %v", profile.CompileTimeout),
+			}
+		}
+		return compileStepResult{timeMs: time.Since(start).Milliseconds()}, fmt.Errorf("error waiting for compile container: %w", err)
+	}
+	if oomKilled {
+		return compileStepResult{timeMs: time.Since(start).Milliseconds()}, ExecutionError{
+			Type:    "memory_limit",
+			Message: "compilation exceeded memory limit",
+		}
+	}
+
+	_, stderr, err := e.runtimeBackend.Logs(context.Background(), handle)
+	if err != nil {
+		return compileStepResult{timeMs: time.Since(start).Milliseconds()}, fmt.Errorf("failed to get compile container logs: %w", err)
+	}
+
+	return compileStepResult{exitCode: exitCode, stderr: stderr, timeMs: time.Since(start).Milliseconds()}, nil
+}
@@ -1,10 +1,11 @@
-// Package executor provides functionality for securely executing code using Docker containers.
+// Package executor provides functionality for securely executing code across pluggable
+// container runtimes (Docker, containerd, gVisor, Singularity) and sandboxes (Firecracker, Wasm).
 package executor
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -12,11 +13,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/strslice"
-	"github.com/docker/docker/client"
-	"github.com/docker/docker/pkg/stdcopy"
 )
 
 // Default resource limits
@@ -33,6 +30,32 @@ const (
 // CodeExecutionService defines the interface for code execution
 type CodeExecutionService interface {
 	Execute(ctx context.Context, req ExecutionRequest) (ExecutionResult, error)
+	ExecuteStream(ctx context.Context, req ExecutionRequest, sink StreamSink) error
+	ExecuteBatch(ctx context.Context, req ExecutionRequest) (BatchResult, error)
+	// Grade compiles Code once (reusing the same compile cache ExecuteBatch's Execute calls
+	// populate) and runs it against each GradeCase concurrently, producing a judge-style
+	// AC/WA/TLE/MLE/RE/CE verdict per case instead of a plain pass/fail.
+	Grade(ctx context.Context, req GradeRequest) (GradeResult, error)
+	// ExecuteInteractive runs req with stdin/stdout/stderr wired live to the given streams
+	// instead of a fixed stdin file and buffered output, for REPL-style programs that prompt
+	// mid-execution. resize carries terminal size changes for the lifetime of the execution;
+	// callers that don't support resizing may pass a nil channel.
+	ExecuteInteractive(ctx context.Context, req ExecutionRequest, stdin io.Reader, stdout, stderr io.Writer, resize <-chan ResizeSpec) error
+}
+
+// StreamChunk is a single piece of output emitted while a streaming execution is in progress.
+type StreamChunk struct {
+	Kind       string // "stdout", "stderr", "exit", or "error"
+	Data       string
+	ExitCode   int64
+	ExecTimeMs int64
+	ErrorType  string
+}
+
+// StreamSink receives StreamChunks as they are produced by ExecuteStream. Implementations
+// must be safe for the concurrent stdout/stderr delivery ExecuteStream performs.
+type StreamSink interface {
+	Send(chunk StreamChunk) error
 }
 
 // SupportedLanguages is a map of languages that the executor supports
@@ -57,6 +80,39 @@ type ExecutionRequest struct {
 	Code     string
 	Stdin    string
 	Timeout  time.Duration // Maximum execution time
+	Files    []BatchFile   // Additional files alongside Code, for multi-file submissions
+	Tests    []TestCase    // When non-empty, ExecuteBatch runs Code against each of these
+}
+
+// BatchFile is a single additional file in a multi-file submission. Path is relative to the
+// execution's working directory, e.g. "helpers/util.py", and is created (along with any
+// parent directories) next to the main code file.
+type BatchFile struct {
+	Path    string
+	Content string
+}
+
+// TestCase is one test-harness case ExecuteBatch runs a prepared program against.
+type TestCase struct {
+	Name           string
+	Stdin          string
+	ExpectedStdout string
+	TimeoutMs      int
+}
+
+// TestResult is the outcome of running a program against a single TestCase.
+type TestResult struct {
+	Name       string
+	Pass       bool
+	Stdout     string
+	Stderr     string
+	ExecTimeMs int64
+	ErrorType  string
+}
+
+// BatchResult is what ExecuteBatch returns: one TestResult per TestCase, in the same order.
+type BatchResult struct {
+	TestResults []TestResult
 }
 
 // ExecutionResult contains the results of code execution
@@ -64,7 +120,12 @@ type ExecutionResult struct {
 	Stdout     string
 	Stderr     string
 	Error      string
-	ExecTimeMs int64
+	ExecTimeMs int64 // Total wall-clock time, including compilation for LanguageCompilers languages
+	// CompileTimeMs and RunTimeMs split ExecTimeMs for LanguageCompilers languages, where
+	// compilation and execution run as two separate containers; both are 0 for interpreted
+	// languages, which have no compile step. CompileTimeMs is also 0 on a compile-cache hit.
+	CompileTimeMs int64
+	RunTimeMs     int64
 }
 
 // ExecutionError represents specific error types that can occur during execution
@@ -122,6 +183,68 @@ func (m *MockExecutor) Execute(_ context.Context, req ExecutionRequest) (Executi
 	return result, nil
 }
 
+// ExecuteStream simulates a streaming execution by running Execute and delivering the
+// result as a single stdout/stderr chunk followed by an exit chunk.
+func (m *MockExecutor) ExecuteStream(ctx context.Context, req ExecutionRequest, sink StreamSink) error {
+	result, err := m.Execute(ctx, req)
+	if err != nil {
+		errType := "runtime"
+		if execErr, ok := err.(ExecutionError); ok {
+			errType = execErr.Type
+		}
+		return sink.Send(StreamChunk{Kind: "error", Data: err.Error(), ErrorType: errType})
+	}
+	if result.Stdout != "" {
+		if err := sink.Send(StreamChunk{Kind: "stdout", Data: result.Stdout}); err != nil {
+			return err
+		}
+	}
+	if result.Stderr != "" {
+		if err := sink.Send(StreamChunk{Kind: "stderr", Data: result.Stderr}); err != nil {
+			return err
+		}
+	}
+	return sink.Send(StreamChunk{Kind: "exit", ExecTimeMs: result.ExecTimeMs})
+}
+
+// ExecuteBatch runs req.Code against each of req.Tests by delegating to Execute once per
+// case, comparing trimmed stdout against each case's ExpectedStdout.
+func (m *MockExecutor) ExecuteBatch(ctx context.Context, req ExecutionRequest) (BatchResult, error) {
+	return runBatch(ctx, m, req)
+}
+
+// ExecuteInteractive simulates an interactive execution: stdin isn't actually live (the mock
+// never reads it), but resize is drained so callers can select on it unconditionally, and the
+// result of Execute is written to stdout/stderr as if it arrived over the attached session.
+func (m *MockExecutor) ExecuteInteractive(ctx context.Context, req ExecutionRequest, _ io.Reader, stdout, stderr io.Writer, resize <-chan ResizeSpec) error {
+	go drainResize(ctx, resize)
+
+	result, err := m.Execute(ctx, req)
+	if err != nil {
+		io.WriteString(stderr, err.Error())
+		return err
+	}
+	io.WriteString(stdout, result.Stdout)
+	io.WriteString(stderr, result.Stderr)
+	return nil
+}
+
+// drainResize discards resize events until resize is closed or ctx is done, so
+// ExecuteInteractive implementations that don't act on resizes still don't leak a sender
+// blocked on an unread channel.
+func drainResize(ctx context.Context, resize <-chan ResizeSpec) {
+	for {
+		select {
+		case _, ok := <-resize:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // Helper function to extract content from print statements for the mock executor
 func extractPrintContent(code, language string) string {
 	var printStart, printEnd string
@@ -155,15 +278,21 @@ func extractPrintContent(code, language string) string {
 	return "[Content could not be extracted]"
 }
 
-// CodeExecutor handles code execution in Docker containers
+// CodeExecutor handles code execution in Docker containers, or in a Firecracker/Wasm
+// Sandbox when ExecutorConfig.SandboxBackend selects one of those instead.
 type CodeExecutor struct {
-	dockerClient       *client.Client
+	runtimeBackend     RuntimeBackend       // Drives containers for container-based SandboxBackends
 	imagePrefix        string               // Prefix for Docker images, e.g. "aggiecode/"
-	fallbackMode       bool                 // Use fallback mode when Docker is not available
+	fallbackMode       bool                 // Use fallback mode when the runtime engine is not available
 	mockExecutor       CodeExecutionService // Mock executor for fallback mode
+	backend            string               // Selected sandbox backend, e.g. BackendDocker
+	runtimeEngine      string               // Selected runtime engine, e.g. EngineDocker, EngineContainerd
+	sandbox            Sandbox              // Driven directly for backends that bypass containers entirely
 	concurrentLimit    int                  // Maximum number of concurrent executions
 	executionSemaphore *chan struct{}       // Semaphore to limit concurrent executions
 	executionLock      sync.Mutex           // Lock to protect concurrent access to the semaphore
+	compileCache       *compileCache        // On-disk cache of compiled artifacts for LanguageCompilers languages
+	warmPool           *WarmPool            // Pool of pre-started containers for interpreted languages; nil when disabled or unsupported by runtimeEngine
 }
 
 // ExecutorConfig provides configuration options for the CodeExecutor
@@ -171,6 +300,21 @@ type ExecutorConfig struct {
 	ImagePrefix     string        // Prefix for Docker images
 	ConcurrentLimit int           // Maximum number of concurrent executions
 	DefaultTimeout  time.Duration // Default timeout for code execution
+	SandboxBackend  string        // BackendDocker (default), BackendGVisor, BackendFirecracker, or BackendWasm
+	RuntimeEngine   string        // EngineDocker (default), EngineContainerd, EngineGVisor, or EngineSingularity; only used when SandboxBackend is container-based
+	CacheDir        string        // On-disk directory for compiled-artifact caching; defaults to a directory under os.TempDir()
+
+	// WarmPoolSize is how many idle containers WarmPool keeps ready per interpreted language;
+	// 0 (the default) disables container pooling entirely. Pooling requires images that ship
+	// the in-image supervisor WarmPool's wire protocol expects, so it's opt-in rather than on
+	// by default.
+	WarmPoolSize int
+	// WarmPoolMaxReuse caps how many Execute calls a pooled container serves before being
+	// recycled; 0 defaults to DefaultWarmPoolMaxReuse.
+	WarmPoolMaxReuse int
+	// WarmPoolIdleTTL caps how long a pooled container sits idle before being discarded
+	// instead of reused; 0 means no limit.
+	WarmPoolIdleTTL time.Duration
 }
 
 // NewExecutor creates a new CodeExecutor instance with default configuration
@@ -193,47 +337,121 @@ func NewExecutorWithConfig(config ExecutorConfig) (*CodeExecutor, error) {
 		config.DefaultTimeout = DefaultExecutionTime
 	}
 
-	// Try to create Docker client
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	backend := config.SandboxBackend
+	if backend == "" {
+		backend = BackendDocker
+	}
 
 	// Create semaphore for limiting concurrent executions
 	semaphore := make(chan struct{}, config.ConcurrentLimit)
 
-	if err != nil {
-		// Docker client creation failed, use fallback mode
-		fmt.Println("WARNING: Could not create Docker client, using fallback mode")
+	// Firecracker and Wasm never touch the Docker daemon; validate and wire their Sandbox
+	// up front so a misconfigured backend fails at startup instead of on the first request.
+	if !isContainerBackend(backend) {
+		sandbox, err := newSandbox(backend)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize sandbox backend %q: %w", backend, err)
+		}
 		return &CodeExecutor{
-			dockerClient:       nil,
 			imagePrefix:        config.ImagePrefix,
-			fallbackMode:       true,
-			mockExecutor:       &MockExecutor{},
+			backend:            backend,
+			sandbox:            sandbox,
 			concurrentLimit:    config.ConcurrentLimit,
 			executionSemaphore: &semaphore,
 		}, nil
 	}
 
-	// Test Docker connection
-	_, err = cli.Ping(context.Background())
+	// Docker and gVisor are the two RuntimeEngines that fall back to MockExecutor when
+	// unavailable, matching the prior behavior of degrading gracefully on a dev machine with
+	// no Docker daemon. Containerd and Singularity are deployment choices an operator opts
+	// into explicitly, so they fail fast at startup instead.
+	engine := config.RuntimeEngine
+	if engine == "" {
+		if backend == BackendGVisor {
+			engine = EngineGVisor
+		} else {
+			engine = EngineDocker
+		}
+	}
+
+	runtimeBackend, err := newRuntimeBackend(engine)
 	if err != nil {
-		// Docker daemon is not running, use fallback mode
-		fmt.Println("WARNING: Docker daemon is not running, using fallback mode")
-		return &CodeExecutor{
-			dockerClient:       nil,
-			imagePrefix:        config.ImagePrefix,
-			fallbackMode:       true,
-			mockExecutor:       &MockExecutor{},
-			concurrentLimit:    config.ConcurrentLimit,
-			executionSemaphore: &semaphore,
-		}, nil
+		if engine == EngineDocker || engine == EngineGVisor {
+			fmt.Printf("WARNING: Could not initialize runtime engine %q, using fallback mode: %v\n", engine, err)
+			return &CodeExecutor{
+				imagePrefix:        config.ImagePrefix,
+				fallbackMode:       true,
+				mockExecutor:       &MockExecutor{},
+				backend:            backend,
+				runtimeEngine:      engine,
+				concurrentLimit:    config.ConcurrentLimit,
+				executionSemaphore: &semaphore,
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to initialize runtime engine %q: %w", engine, err)
+	}
+
+	cache, err := newCompileCache(config.CacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize compile cache: %w", err)
+	}
+
+	var warmPool *WarmPool
+	if config.WarmPoolSize > 0 {
+		if poolable, ok := runtimeBackend.(PoolableRuntimeBackend); ok {
+			imagePrefix := config.ImagePrefix
+			buildSpec := func(language string) (ContainerSpec, bool) {
+				image, supported := SupportedLanguages[language]
+				if !supported {
+					return ContainerSpec{}, false
+				}
+				if imagePrefix != "" {
+					image = imagePrefix + image
+				}
+				prof := profileFor(language)
+				return ContainerSpec{
+					ImageName:  image,
+					Cmd:        []string{"/opt/aggiecode/supervisor", language},
+					Memory:     prof.Memory,
+					MemorySwap: prof.MemorySwap,
+					NanoCPUs:   prof.NanoCPUs,
+					PidsLimit:  prof.PidsLimit,
+					TmpfsSize:  prof.TmpfsSize,
+					Ulimits:    prof.Ulimits,
+				}, true
+			}
+
+			maxReuse := config.WarmPoolMaxReuse
+			if maxReuse <= 0 {
+				maxReuse = DefaultWarmPoolMaxReuse
+			}
+			warmPool = newWarmPool(poolable, buildSpec, config.WarmPoolSize, maxReuse, config.WarmPoolIdleTTL)
+
+			// Pooling only covers interpreted languages for now: LanguageCompilers languages
+			// already get a similar cold-start win from the compile cache, and reusing a warm
+			// container for them would mean keeping compiled artifacts warm inside it too.
+			for language := range SupportedLanguages {
+				if LanguageCompilers[language] {
+					continue
+				}
+				go warmPool.Prewarm(context.Background(), language)
+			}
+		} else {
+			fmt.Printf("WARNING: runtime engine %q does not support container pooling; WarmPoolSize is ignored\n", engine)
+		}
 	}
 
 	return &CodeExecutor{
-		dockerClient:       cli,
+		runtimeBackend:     runtimeBackend,
 		imagePrefix:        config.ImagePrefix,
 		fallbackMode:       false,
 		mockExecutor:       nil,
+		backend:            backend,
+		runtimeEngine:      engine,
 		concurrentLimit:    config.ConcurrentLimit,
 		executionSemaphore: &semaphore,
+		compileCache:       cache,
+		warmPool:           warmPool,
 	}, nil
 }
 
@@ -245,8 +463,9 @@ func (e *CodeExecutor) Execute(ctx context.Context, req ExecutionRequest) (Execu
 	}
 
 	// Validate the timeout
+	profile := profileFor(req.Language)
 	if req.Timeout <= 0 {
-		req.Timeout = DefaultExecutionTime
+		req.Timeout = profile.RunTimeout
 	} else if req.Timeout > MaxExecutionTime {
 		req.Timeout = MaxExecutionTime
 	}
@@ -298,6 +517,14 @@ func (e *CodeExecutor) Execute(ctx context.Context, req ExecutionRequest) (Execu
 		imageName = e.imagePrefix + imageName
 	}
 
+	// A warm-pool hit skips container creation (and the temp directory below) entirely; a miss
+	// falls through to the normal from-scratch path exactly as if the pool didn't exist.
+	if e.warmPool != nil && !LanguageCompilers[req.Language] && len(req.Files) == 0 {
+		if result, ok := e.tryWarmExecute(execCtx, req, startTime); ok {
+			return result, nil
+		}
+	}
+
 	// Create temporary directory for code files
 	tempDir, err := ioutil.TempDir("", fmt.Sprintf("aggiecode-%s-", req.Language))
 	if err != nil {
@@ -320,8 +547,33 @@ func (e *CodeExecutor) Execute(ctx context.Context, req ExecutionRequest) (Execu
 		}
 	}
 
+	// Write any additional files for multi-file submissions alongside the main code file.
+	if err := writeBatchFiles(tempDir, req.Files); err != nil {
+		return result, fmt.Errorf("failed to write submission files: %w", err)
+	}
+
+	if !isContainerBackend(e.backend) {
+		return e.executeViaSandbox(execCtx, req, startTime, tempDir, filename, stdinFile)
+	}
+
+	if LanguageCompilers[req.Language] {
+		return e.executeCompiled(execCtx, req, startTime, tempDir, filename, stdinFile, imageName, profile)
+	}
+
+	spec := ContainerSpec{
+		ImageName:  imageName,
+		Cmd:        e.buildCommand(filepath.Base(filename), filepath.Base(stdinFile), req.Language),
+		TempDir:    tempDir,
+		Memory:     profile.Memory,
+		MemorySwap: profile.MemorySwap,
+		NanoCPUs:   profile.NanoCPUs,
+		PidsLimit:  profile.PidsLimit,
+		TmpfsSize:  profile.TmpfsSize,
+		Ulimits:    profile.Ulimits,
+	}
+
 	// Create and run the container
-	containerID, err := e.createAndStartContainer(execCtx, imageName, tempDir, filename, stdinFile, req.Language)
+	handle, err := e.runtimeBackend.CreateAndStart(execCtx, spec)
 	if err != nil {
 		if execCtx.Err() == context.DeadlineExceeded {
 			return result, ExecutionError{
@@ -331,40 +583,22 @@ func (e *CodeExecutor) Execute(ctx context.Context, req ExecutionRequest) (Execu
 		}
 		return result, fmt.Errorf("container execution failed: %w", err)
 	}
-	defer e.cleanupContainer(context.Background(), containerID)
+	defer e.runtimeBackend.Cleanup(context.Background(), handle)
 
 	// Wait for the container to finish with timeout
-	statusCh, errCh := e.dockerClient.ContainerWait(execCtx, containerID, container.WaitConditionNotRunning)
-	var statusCode int64
-
-	select {
-	case err := <-errCh:
-		if execCtx.Err() == context.DeadlineExceeded {
-			// Context deadline exceeded - execution timed out
-			return result, ExecutionError{
-				Type:    "timeout",
-				Message: fmt.Sprintf("execution timed out after %v", req.Timeout),
-			}
-		}
-		if err != nil {
-			return result, fmt.Errorf("error waiting for container: %w", err)
-		}
-	case status := <-statusCh:
-		statusCode = status.StatusCode
-	case <-execCtx.Done():
-		// Context canceled or timed out
+	statusCode, oomKilled, err := e.runtimeBackend.Wait(execCtx, handle)
+	if err != nil {
 		if execCtx.Err() == context.DeadlineExceeded {
 			return result, ExecutionError{
 				Type:    "timeout",
 				Message: fmt.Sprintf("execution timed out after %v", req.Timeout),
 			}
 		}
-		return result, fmt.Errorf("execution canceled: %v", execCtx.Err())
+		return result, fmt.Errorf("error waiting for container: %w", err)
 	}
 
 	// Check if the container was killed due to OOM (out of memory)
-	containerJSON, err := e.dockerClient.ContainerInspect(context.Background(), containerID)
-	if err == nil && containerJSON.State != nil && containerJSON.State.OOMKilled {
+	if oomKilled {
 		return result, ExecutionError{
 			Type:    "memory_limit",
 			Message: "execution exceeded memory limit",
@@ -372,7 +606,7 @@ func (e *CodeExecutor) Execute(ctx context.Context, req ExecutionRequest) (Execu
 	}
 
 	// Get container logs
-	stdout, stderr, err := e.getContainerLogs(context.Background(), containerID)
+	stdout, stderr, err := e.runtimeBackend.Logs(context.Background(), handle)
 	if err != nil {
 		return result, fmt.Errorf("failed to get container logs: %w", err)
 	}
@@ -382,76 +616,379 @@ func (e *CodeExecutor) Execute(ctx context.Context, req ExecutionRequest) (Execu
 	result.Stderr = stderr
 	result.ExecTimeMs = time.Since(startTime).Milliseconds()
 
-	// Handle non-zero exit codes
+	// LanguageCompilers languages are routed through executeCompiled above and never reach
+	// here, so any non-zero exit at this point is always a runtime error, not a compile one.
 	if statusCode != 0 {
-		// Check if this is a compilation error (for compiled languages)
-		if needsCompilation, ok := LanguageCompilers[req.Language]; ok && needsCompilation {
-			if strings.Contains(stderr, "error") || strings.Contains(stderr, "Error") {
-				result.Error = fmt.Sprintf("Compilation error (exit code %d)", statusCode)
-			} else {
-				result.Error = fmt.Sprintf("Runtime error (exit code %d)", statusCode)
+		result.Error = fmt.Sprintf("Process exited with code %d", statusCode)
+	}
+
+	return result, nil
+}
+
+// ExecuteStream runs the provided code the same way Execute does, but pushes stdout/stderr
+// to sink as they are produced instead of buffering them until the container exits. This
+// avoids the 35s WriteTimeout hiding output from long-running programs.
+func (e *CodeExecutor) ExecuteStream(ctx context.Context, req ExecutionRequest, sink StreamSink) error {
+	if e.fallbackMode {
+		return e.mockExecutor.ExecuteStream(ctx, req, sink)
+	}
+
+	profile := profileFor(req.Language)
+
+	// Firecracker and Wasm don't expose container logs to follow; buffer through Execute
+	// and replay the result as a single stdout/stderr chunk, the same way MockExecutor does.
+	if !isContainerBackend(e.backend) {
+		result, err := e.Execute(ctx, req)
+		if err != nil {
+			errType := "runtime"
+			if execErr, ok := err.(ExecutionError); ok {
+				errType = execErr.Type
 			}
-		} else {
-			result.Error = fmt.Sprintf("Process exited with code %d", statusCode)
+			return sink.Send(StreamChunk{Kind: "error", Data: err.Error(), ErrorType: errType})
 		}
+		if result.Stdout != "" {
+			if err := sink.Send(StreamChunk{Kind: "stdout", Data: result.Stdout}); err != nil {
+				return err
+			}
+		}
+		if result.Stderr != "" {
+			if err := sink.Send(StreamChunk{Kind: "stderr", Data: result.Stderr}); err != nil {
+				return err
+			}
+		}
+		return sink.Send(StreamChunk{Kind: "exit", ExecTimeMs: result.ExecTimeMs})
 	}
 
-	return result, nil
+	if req.Timeout <= 0 {
+		req.Timeout = DefaultExecutionTime
+	} else if req.Timeout > MaxExecutionTime {
+		req.Timeout = MaxExecutionTime
+	}
+
+	e.executionLock.Lock()
+	select {
+	case *e.executionSemaphore <- struct{}{}:
+		e.executionLock.Unlock()
+		defer func() { <-*e.executionSemaphore }()
+	case <-ctx.Done():
+		e.executionLock.Unlock()
+		return sink.Send(StreamChunk{Kind: "error", ErrorType: "timeout", Data: "execution queue is full, try again later"})
+	default:
+		e.executionLock.Unlock()
+		return sink.Send(StreamChunk{Kind: "error", ErrorType: "limit_exceeded", Data: "too many concurrent executions, try again later"})
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	startTime := time.Now()
+
+	imageName, supported := SupportedLanguages[req.Language]
+	if !supported {
+		return sink.Send(StreamChunk{Kind: "error", ErrorType: "unsupported_language", Data: fmt.Sprintf("unsupported language: %s", req.Language)})
+	}
+	if e.imagePrefix != "" {
+		imageName = e.imagePrefix + imageName
+	}
+
+	tempDir, err := ioutil.TempDir("", fmt.Sprintf("aggiecode-%s-", req.Language))
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filename, err := e.writeCodeFile(tempDir, req.Language, req.Code)
+	if err != nil {
+		return fmt.Errorf("failed to write code file: %w", err)
+	}
+
+	var stdinFile string
+	if req.Stdin != "" {
+		stdinFile = filepath.Join(tempDir, "input.txt")
+		if err := ioutil.WriteFile(stdinFile, []byte(req.Stdin), 0644); err != nil {
+			return fmt.Errorf("failed to write stdin file: %w", err)
+		}
+	}
+
+	spec := ContainerSpec{
+		ImageName:  imageName,
+		Cmd:        e.buildCommand(filepath.Base(filename), filepath.Base(stdinFile), req.Language),
+		TempDir:    tempDir,
+		Memory:     profile.Memory,
+		MemorySwap: profile.MemorySwap,
+		NanoCPUs:   profile.NanoCPUs,
+		PidsLimit:  profile.PidsLimit,
+		TmpfsSize:  profile.TmpfsSize,
+		Ulimits:    profile.Ulimits,
+	}
+
+	streamer, ok := e.runtimeBackend.(StreamingRuntimeBackend)
+	if !ok {
+		// The runtime engine can't follow logs live; buffer through Execute and replay the
+		// result as a single chunk, the same way the non-container-backend branch above does.
+		result, err := e.Execute(ctx, req)
+		if err != nil {
+			errType := "runtime"
+			if execErr, ok := err.(ExecutionError); ok {
+				errType = execErr.Type
+			}
+			return sink.Send(StreamChunk{Kind: "error", Data: err.Error(), ErrorType: errType})
+		}
+		if result.Stdout != "" {
+			if err := sink.Send(StreamChunk{Kind: "stdout", Data: result.Stdout}); err != nil {
+				return err
+			}
+		}
+		if result.Stderr != "" {
+			if err := sink.Send(StreamChunk{Kind: "stderr", Data: result.Stderr}); err != nil {
+				return err
+			}
+		}
+		return sink.Send(StreamChunk{Kind: "exit", ExecTimeMs: result.ExecTimeMs})
+	}
+
+	containerResult, err := streamer.Stream(execCtx, spec, sink)
+	if err != nil {
+		if execCtx.Err() == context.DeadlineExceeded {
+			return sink.Send(StreamChunk{Kind: "error", ErrorType: "timeout", Data: fmt.Sprintf("execution timed out after %v", req.Timeout)})
+		}
+		return fmt.Errorf("container execution failed: %w", err)
+	}
+
+	return sink.Send(StreamChunk{
+		Kind:       "exit",
+		ExitCode:   containerResult.ExitCode,
+		ExecTimeMs: time.Since(startTime).Milliseconds(),
+	})
 }
 
-// createAndStartContainer creates and starts a Docker container for code execution
-func (e *CodeExecutor) createAndStartContainer(ctx context.Context, imageName, tempDir, codeFile, stdinFile, language string) (string, error) {
-	// Prepare mount for code directory
-	mounts := []mount.Mount{
-		{
-			Type:     mount.TypeBind,
-			Source:   tempDir,
-			Target:   "/code",
-			ReadOnly: false, // Enable writing for compilation outputs
-		},
+// ExecuteInteractive runs req with a TTY attached live instead of redirecting a fixed stdin
+// file and collecting logs afterwards, for REPL-style programs (python3 -i, node, gdb) that
+// prompt mid-execution. Only runtime engines that implement InteractiveRuntimeBackend support
+// this; Firecracker, Wasm, and engines without a live attach path return an error.
+func (e *CodeExecutor) ExecuteInteractive(ctx context.Context, req ExecutionRequest, stdin io.Reader, stdout, stderr io.Writer, resize <-chan ResizeSpec) error {
+	if e.fallbackMode {
+		return e.mockExecutor.ExecuteInteractive(ctx, req, stdin, stdout, stderr, resize)
+	}
+
+	if !isContainerBackend(e.backend) {
+		return fmt.Errorf("interactive execution requires a container-based backend, got %q", e.backend)
 	}
 
-	// Set up command based on language
-	cmd := e.buildCommand(filepath.Base(codeFile), filepath.Base(stdinFile), language)
+	profile := profileFor(req.Language)
 
-	// Create container configuration
-	config := &container.Config{
-		Image:      imageName,
-		Cmd:        cmd,
-		Tty:        false,
-		WorkingDir: "/code", // Set working directory
+	interactive, ok := e.runtimeBackend.(InteractiveRuntimeBackend)
+	if !ok {
+		return fmt.Errorf("runtime engine %q does not support interactive execution", e.runtimeEngine)
 	}
 
-	// Convert CPU limit from core count to nano-CPUs
-	nanoCPUs := int64(DefaultCPULimit * 1e9)
+	if req.Timeout <= 0 {
+		req.Timeout = DefaultExecutionTime
+	} else if req.Timeout > MaxExecutionTime {
+		req.Timeout = MaxExecutionTime
+	}
 
-	// Store our pids limit
-	pidsLimit := DefaultPidsLimit
+	e.executionLock.Lock()
+	select {
+	case *e.executionSemaphore <- struct{}{}:
+		e.executionLock.Unlock()
+		defer func() { <-*e.executionSemaphore }()
+	case <-ctx.Done():
+		e.executionLock.Unlock()
+		return ExecutionError{Type: "timeout", Message: "execution queue is full, try again later"}
+	default:
+		e.executionLock.Unlock()
+		return ExecutionError{Type: "limit_exceeded", Message: "too many concurrent executions, try again later"}
+	}
 
-	// Create host configuration with security settings
-	hostConfig := &container.HostConfig{
-		Mounts:         mounts,
-		NetworkMode:    container.NetworkMode(DefaultNetworkPolicy), // Disable networking
-		ReadonlyRootfs: true,                                        // Read-only filesystem for security
-		Resources: container.Resources{
-			Memory:    DefaultMemoryLimit, // Memory limit
-			NanoCPUs:  nanoCPUs,           // CPU limit
-			PidsLimit: &pidsLimit,         // Process limit
-		},
+	execCtx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	imageName, supported := SupportedLanguages[req.Language]
+	if !supported {
+		return ExecutionError{Type: "unsupported_language", Message: fmt.Sprintf("unsupported language: %s", req.Language)}
+	}
+	if e.imagePrefix != "" {
+		imageName = e.imagePrefix + imageName
+	}
+
+	tempDir, err := ioutil.TempDir("", fmt.Sprintf("aggiecode-%s-", req.Language))
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
 	}
+	defer os.RemoveAll(tempDir)
 
-	// Create the container with updated API
-	resp, err := e.dockerClient.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	filename, err := e.writeCodeFile(tempDir, req.Language, req.Code)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to write code file: %w", err)
 	}
 
-	// Start the container
-	if err := e.dockerClient.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
-		return resp.ID, err // Return ID for cleanup, even though start failed
+	if err := writeBatchFiles(tempDir, req.Files); err != nil {
+		return fmt.Errorf("failed to write submission files: %w", err)
 	}
 
-	return resp.ID, nil
+	spec := ContainerSpec{
+		ImageName: imageName,
+		// Interactive stdin is attached live below, not redirected from a file.
+		Cmd:        e.buildCommand(filepath.Base(filename), "", req.Language),
+		TempDir:    tempDir,
+		Memory:     profile.Memory,
+		MemorySwap: profile.MemorySwap,
+		NanoCPUs:   profile.NanoCPUs,
+		PidsLimit:  profile.PidsLimit,
+		TmpfsSize:  profile.TmpfsSize,
+		Ulimits:    profile.Ulimits,
+	}
+
+	session, err := interactive.Attach(execCtx, spec, stdin, stdout, stderr)
+	if err != nil {
+		return fmt.Errorf("failed to attach interactive session: %w", err)
+	}
+	defer session.Close()
+
+	go func() {
+		for {
+			select {
+			case r, ok := <-resize:
+				if !ok {
+					return
+				}
+				session.Resize(execCtx, r)
+			case <-execCtx.Done():
+				return
+			}
+		}
+	}()
+
+	result, err := session.Wait(execCtx)
+	if err != nil {
+		if execCtx.Err() == context.DeadlineExceeded {
+			return ExecutionError{Type: "timeout", Message: fmt.Sprintf("execution timed out after %v", req.Timeout)}
+		}
+		return fmt.Errorf("error waiting for interactive session: %w", err)
+	}
+	if result.OOMKilled {
+		return ExecutionError{Type: "memory_limit", Message: "execution exceeded memory limit"}
+	}
+
+	return nil
+}
+
+// sinkWriter adapts a StreamSink to io.Writer so stdcopy.StdCopy can demux container
+// output directly into stdout/stderr chunks as they arrive.
+type sinkWriter struct {
+	sink StreamSink
+	kind string
+}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := w.sink.Send(StreamChunk{Kind: w.kind, Data: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ExecuteBatch runs req.Code against each of req.Tests through the normal Execute path,
+// reporting pass/fail per case. This is the primary entry point for using the service as a
+// programming-judge backend.
+//
+// For LanguageCompilers languages the first case compiles through executeCompiled and the
+// later cases hit the compile cache, so compilation is not repeated per case.
+func (e *CodeExecutor) ExecuteBatch(ctx context.Context, req ExecutionRequest) (BatchResult, error) {
+	return runBatch(ctx, e, req)
+}
+
+// runBatch is shared by CodeExecutor and MockExecutor: it runs executor.Execute once per
+// TestCase in req.Tests and turns each ExecutionResult into a pass/fail TestResult.
+func runBatch(ctx context.Context, executor CodeExecutionService, req ExecutionRequest) (BatchResult, error) {
+	if len(req.Tests) == 0 {
+		return BatchResult{}, fmt.Errorf("ExecuteBatch requires at least one test case")
+	}
+
+	results := make([]TestResult, len(req.Tests))
+	for i, tc := range req.Tests {
+		caseReq := req
+		caseReq.Stdin = tc.Stdin
+		caseReq.Tests = nil
+		if tc.TimeoutMs > 0 {
+			caseReq.Timeout = time.Duration(tc.TimeoutMs) * time.Millisecond
+		}
+
+		result, err := executor.Execute(ctx, caseReq)
+		tr := TestResult{
+			Name:       tc.Name,
+			Stdout:     result.Stdout,
+			Stderr:     result.Stderr,
+			ExecTimeMs: result.ExecTimeMs,
+		}
+		switch {
+		case err != nil:
+			if execErr, ok := err.(ExecutionError); ok {
+				tr.ErrorType = execErr.Type
+			} else {
+				tr.ErrorType = "runtime"
+			}
+		case result.Error != "":
+			tr.ErrorType = "runtime"
+		default:
+			tr.Pass = strings.TrimSpace(result.Stdout) == strings.TrimSpace(tc.ExpectedStdout)
+		}
+		results[i] = tr
+	}
+
+	return BatchResult{TestResults: results}, nil
+}
+
+// executeViaSandbox runs req through e.sandbox for backends (Firecracker, Wasm) that have no
+// Docker container to create, translating SandboxResult into the same ExecutionResult/
+// ExecutionError shape the Docker path produces.
+func (e *CodeExecutor) executeViaSandbox(ctx context.Context, req ExecutionRequest, startTime time.Time, tempDir, codeFile, stdinFile string) (ExecutionResult, error) {
+	profile := profileFor(req.Language)
+	spec := SandboxSpec{
+		Language:  req.Language,
+		TempDir:   tempDir,
+		CodeFile:  codeFile,
+		StdinFile: stdinFile,
+		Memory:    profile.Memory,
+		NanoCPUs:  profile.NanoCPUs,
+		PidsLimit: profile.PidsLimit,
+	}
+
+	handle, err := e.sandbox.Prepare(ctx, spec)
+	if err != nil {
+		return ExecutionResult{}, fmt.Errorf("sandbox prepare failed: %w", err)
+	}
+	defer e.sandbox.Destroy(handle)
+
+	sbResult, err := e.sandbox.Run(ctx, handle)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return ExecutionResult{}, ExecutionError{
+				Type:    "timeout",
+				Message: fmt.Sprintf("execution timed out after %v", req.Timeout),
+			}
+		}
+		return ExecutionResult{}, fmt.Errorf("sandbox run failed: %w", err)
+	}
+	if sbResult.OOMKilled {
+		return ExecutionResult{}, ExecutionError{
+			Type:    "memory_limit",
+			Message: "execution exceeded memory limit",
+		}
+	}
+
+	result := ExecutionResult{
+		Stdout:     sbResult.Stdout,
+		Stderr:     sbResult.Stderr,
+		ExecTimeMs: time.Since(startTime).Milliseconds(),
+	}
+	if sbResult.ExitCode != 0 {
+		result.Error = fmt.Sprintf("Process exited with code %d", sbResult.ExitCode)
+	}
+	return result, nil
 }
 
 // buildCommand constructs the command to run based on the language and files
@@ -528,40 +1065,19 @@ func (e *CodeExecutor) writeCodeFile(tempDir, language, code string) (string, er
 	return filename, ioutil.WriteFile(filename, []byte(code), 0644)
 }
 
-// getContainerLogs retrieves the stdout and stderr from the container
-func (e *CodeExecutor) getContainerLogs(ctx context.Context, containerID string) (string, string, error) {
-	// Get logs from the container
-	reader, err := e.dockerClient.ContainerLogs(ctx, containerID, container.LogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-	})
-	if err != nil {
-		return "", "", err
-	}
-	defer reader.Close()
-
-	// Separate stdout and stderr
-	var stdout, stderr bytes.Buffer
-	_, err = stdcopy.StdCopy(&stdout, &stderr, reader)
-	if err != nil {
-		return "", "", err
-	}
-
-	return stdout.String(), stderr.String(), nil
-}
-
-// cleanupContainer removes the container after execution
-func (e *CodeExecutor) cleanupContainer(ctx context.Context, containerID string) {
-	// First try to stop the container gracefully
-	stopTimeout := 1 // 1 second timeout for stopping
-	err := e.dockerClient.ContainerStop(ctx, containerID, container.StopOptions{Timeout: &stopTimeout})
-	if err != nil {
-		// If stopping fails, try to kill it
-		e.dockerClient.ContainerKill(ctx, containerID, "SIGKILL")
+// writeBatchFiles writes each BatchFile under tempDir, creating any parent directories its
+// Path implies. Paths are joined with filepath.Join, so a Path like "../etc/passwd" resolves
+// outside tempDir the same way it would for any other caller of this pattern; callers are
+// expected to be trusted submission sources, not untrusted user-controlled paths.
+func writeBatchFiles(tempDir string, files []BatchFile) error {
+	for _, f := range files {
+		dest := filepath.Join(tempDir, f.Path)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(dest, []byte(f.Content), 0644); err != nil {
+			return err
+		}
 	}
-
-	// Remove the container
-	e.dockerClient.ContainerRemove(ctx, containerID, container.RemoveOptions{
-		Force: true,
-	})
+	return nil
 }
@@ -0,0 +1,227 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRuntimeBackend is an in-process RuntimeBackend (and PoolableRuntimeBackend) fake, so
+// CodeExecutor's own methods - not a hand-rolled CodeExecutionService like main_test.go's
+// TestExecutor - get exercised without a real Docker daemon.
+type fakeRuntimeBackend struct {
+	mu      sync.Mutex
+	handles map[string]fakeRun
+
+	// createErr, waitErr, and oomKilled let a test steer a single CreateAndStart/Wait call
+	// without needing a real container to misbehave. blockCreateUntilDone makes CreateAndStart
+	// wait out ctx's deadline before returning createErr, so a timeout test doesn't race the
+	// temp-directory setup Execute does before it ever calls CreateAndStart.
+	createErr            error
+	waitErr              error
+	oomKilled            bool
+	blockCreateUntilDone bool
+
+	// nextHandle is a counter rather than a random ID, so tests stay deterministic.
+	nextHandle int
+}
+
+// fakeRun is what fakeRuntimeBackend remembers about one CreateAndStart call, so Logs can
+// report something derived from the spec that created it.
+type fakeRun struct {
+	spec ContainerSpec
+}
+
+func newFakeRuntimeBackend() *fakeRuntimeBackend {
+	return &fakeRuntimeBackend{handles: make(map[string]fakeRun)}
+}
+
+func (f *fakeRuntimeBackend) CreateAndStart(ctx context.Context, spec ContainerSpec) (string, error) {
+	if f.blockCreateUntilDone {
+		<-ctx.Done()
+		return "", f.createErr
+	}
+	if f.createErr != nil {
+		return "", f.createErr
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextHandle++
+	handle := strings.Join([]string{"fake", spec.ImageName, strings.Repeat("h", f.nextHandle)}, "-")
+	f.handles[handle] = fakeRun{spec: spec}
+	return handle, nil
+}
+
+func (f *fakeRuntimeBackend) Wait(ctx context.Context, handle string) (int64, bool, error) {
+	if f.waitErr != nil {
+		return 0, false, f.waitErr
+	}
+	if f.oomKilled {
+		return 137, true, nil
+	}
+	return 0, false, nil
+}
+
+// Logs reports "ran " plus the image name of the spec that created handle as the container's
+// stdout, so a test can assert that Execute's result came from the image it selected.
+func (f *fakeRuntimeBackend) Logs(ctx context.Context, handle string) (string, string, error) {
+	f.mu.Lock()
+	run, ok := f.handles[handle]
+	f.mu.Unlock()
+	if !ok {
+		return "", "", nil
+	}
+	return "ran " + run.spec.ImageName, "", nil
+}
+
+func (f *fakeRuntimeBackend) Cleanup(ctx context.Context, handle string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.handles, handle)
+}
+
+// Prewarm and Exec make fakeRuntimeBackend double as a PoolableRuntimeBackend, so WarmPool's
+// Acquire/Release path can be exercised the same way Execute's cold path is.
+func (f *fakeRuntimeBackend) Prewarm(ctx context.Context, spec ContainerSpec) (string, error) {
+	return f.CreateAndStart(ctx, spec)
+}
+
+func (f *fakeRuntimeBackend) Exec(ctx context.Context, handle, code, stdin string, timeout time.Duration) (string, string, int64, error) {
+	return "warm: " + code, "", 0, nil
+}
+
+func (f *fakeRuntimeBackend) DiscardWarm(handle string) {
+	f.Cleanup(context.Background(), handle)
+}
+
+func newTestExecutor(t *testing.T, backend *fakeRuntimeBackend) *CodeExecutor {
+	t.Helper()
+	semaphore := make(chan struct{}, DefaultConcurrentLimit)
+	return &CodeExecutor{
+		runtimeBackend:     backend,
+		backend:            BackendDocker,
+		runtimeEngine:      EngineDocker,
+		concurrentLimit:    DefaultConcurrentLimit,
+		executionSemaphore: &semaphore,
+	}
+}
+
+func TestNewExecutorWithConfigFallsBackWithoutDocker(t *testing.T) {
+	// EngineContainerd/EngineSingularity fail fast, but EngineDocker/EngineGVisor degrade to
+	// MockExecutor - there's no Docker daemon in this sandbox, so this exercises the same
+	// fallback path a dev machine without Docker hits.
+	executor, err := NewExecutorWithConfig(ExecutorConfig{RuntimeEngine: EngineDocker})
+	if err != nil {
+		t.Fatalf("NewExecutorWithConfig() error = %v, want nil (fallback mode)", err)
+	}
+	if !executor.fallbackMode {
+		t.Error("fallbackMode = false, want true with no Docker daemon reachable")
+	}
+	if _, ok := executor.mockExecutor.(*MockExecutor); !ok {
+		t.Errorf("mockExecutor = %T, want *MockExecutor", executor.mockExecutor)
+	}
+}
+
+func TestNewExecutorWithConfigFirecrackerRequiresEnv(t *testing.T) {
+	_, err := NewExecutorWithConfig(ExecutorConfig{SandboxBackend: BackendFirecracker})
+	if err == nil {
+		t.Fatal("NewExecutorWithConfig() error = nil, want an error for a missing firecracker binary/FIRECRACKER_ROOTFS_DIR")
+	}
+}
+
+func TestExecuteRunsAgainstFakeBackend(t *testing.T) {
+	e := newTestExecutor(t, newFakeRuntimeBackend())
+
+	result, err := e.Execute(context.Background(), ExecutionRequest{Language: "python", Code: "print('hi')"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v, want nil", err)
+	}
+	if !strings.Contains(result.Stdout, "python-executor") {
+		t.Errorf("Stdout = %q, want it to reflect the python-executor image the fake backend saw", result.Stdout)
+	}
+}
+
+func TestExecuteUnsupportedLanguage(t *testing.T) {
+	e := newTestExecutor(t, newFakeRuntimeBackend())
+
+	_, err := e.Execute(context.Background(), ExecutionRequest{Language: "rust", Code: "fn main() {}"})
+	execErr, ok := err.(ExecutionError)
+	if !ok || execErr.Type != "unsupported_language" {
+		t.Errorf("Execute() error = %v, want ExecutionError{Type: unsupported_language}", err)
+	}
+}
+
+func TestExecuteOOMKilled(t *testing.T) {
+	backend := newFakeRuntimeBackend()
+	backend.oomKilled = true
+	e := newTestExecutor(t, backend)
+
+	_, err := e.Execute(context.Background(), ExecutionRequest{Language: "python", Code: "print('hi')"})
+	execErr, ok := err.(ExecutionError)
+	if !ok || execErr.Type != "memory_limit" {
+		t.Errorf("Execute() error = %v, want ExecutionError{Type: memory_limit}", err)
+	}
+}
+
+func TestExecuteContainerCreationTimeout(t *testing.T) {
+	backend := newFakeRuntimeBackend()
+	backend.blockCreateUntilDone = true
+	backend.createErr = context.DeadlineExceeded
+	e := newTestExecutor(t, backend)
+
+	_, err := e.Execute(context.Background(), ExecutionRequest{Language: "python", Code: "print('hi')", Timeout: 10 * time.Millisecond})
+	execErr, ok := err.(ExecutionError)
+	if !ok || execErr.Type != "timeout" {
+		t.Errorf("Execute() error = %v, want ExecutionError{Type: timeout}", err)
+	}
+}
+
+func TestWarmPoolAcquireRelease(t *testing.T) {
+	backend := newFakeRuntimeBackend()
+	buildSpec := func(language string) (ContainerSpec, bool) {
+		image, ok := SupportedLanguages[language]
+		return ContainerSpec{ImageName: image}, ok
+	}
+	pool := newWarmPool(backend, buildSpec, 1, DefaultWarmPoolMaxReuse, 0)
+	pool.Prewarm(context.Background(), "python")
+
+	e := newTestExecutor(t, backend)
+	e.warmPool = pool
+
+	result, ok := e.tryWarmExecute(context.Background(), ExecutionRequest{Language: "python", Code: "print('hi')"}, time.Now())
+	if !ok {
+		t.Fatal("tryWarmExecute() ok = false, want true on a prewarmed pool")
+	}
+	if !strings.Contains(result.Stdout, "print('hi')") {
+		t.Errorf("Stdout = %q, want it to contain the code the fake supervisor echoed", result.Stdout)
+	}
+
+	// Release puts the container back, so a second acquire should hit the pool again rather
+	// than falling back to the cold path.
+	if _, ok := e.warmPool.Acquire("python"); !ok {
+		t.Error("Acquire() ok = false after Release, want the container to have been returned to the pool")
+	}
+}
+
+func TestGradeRunsEachCase(t *testing.T) {
+	e := newTestExecutor(t, newFakeRuntimeBackend())
+
+	result, err := e.Grade(context.Background(), GradeRequest{
+		Language: "python",
+		Code:     "print('hi')",
+		Cases: []GradeCase{
+			{Name: "case1", ExpectedStdout: "ran python-executor"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Grade() error = %v, want nil", err)
+	}
+	if len(result.Cases) != 1 {
+		t.Fatalf("len(Cases) = %d, want 1", len(result.Cases))
+	}
+	if result.Verdict != VerdictAC {
+		t.Errorf("Verdict = %v, want %v (stderr=%q)", result.Verdict, VerdictAC, result.Cases[0].Stderr)
+	}
+}
@@ -0,0 +1,493 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CheckerKind selects how Grade compares a case's actual stdout against its ExpectedStdout.
+type CheckerKind string
+
+const (
+	CheckerTrimmed               CheckerKind = "trimmed"                // strings.TrimSpace both sides; the default
+	CheckerExact                 CheckerKind = "exact"                  // byte-for-byte equality
+	CheckerWhitespaceInsensitive CheckerKind = "whitespace_insensitive" // compare with all runs of whitespace collapsed
+	CheckerFloatTolerance        CheckerKind = "float_tolerance"        // compare whitespace-separated tokens as floats within GradeRequest.FloatTolerance
+	CheckerCustom                CheckerKind = "custom"                 // run GradeRequest.CheckerCommand in a sandbox container
+)
+
+// GradeRequest is one program graded against N independent test cases.
+type GradeRequest struct {
+	Language string
+	Code     string
+	Files    []BatchFile
+	Cases    []GradeCase
+
+	Checker        CheckerKind // defaults to CheckerTrimmed when empty
+	FloatTolerance float64     // used when Checker is CheckerFloatTolerance
+	// CheckerCommand is run as `/bin/sh -c CheckerCommand` in CheckerImage when Checker is
+	// CheckerCustom, with the case's actual/expected stdout available as /code/actual.txt and
+	// /code/expected.txt; a zero exit code means the case passes.
+	CheckerCommand string
+	CheckerImage   string // defaults to imagePrefix + "checker" when empty
+
+	// Parallelism caps how many cases run at once; 0 defaults to 4, and it is further capped
+	// at the executor's own ConcurrentLimit. Cases do not take slots from executionSemaphore,
+	// so a Grade call's containers are not counted against concurrent Execute calls.
+	Parallelism int
+}
+
+// GradeCase is one test case: stdin in, expected stdout out, with optional per-case overrides
+// of the language's default timeout/memory profile.
+type GradeCase struct {
+	Name           string
+	Stdin          string
+	ExpectedStdout string
+	TimeoutMs      int
+	MemoryBytes    int64
+}
+
+// GradeVerdict is a judge-style verdict for a single GradeCase, or for a GradeResult as a whole.
+type GradeVerdict string
+
+const (
+	VerdictAC  GradeVerdict = "AC"  // Accepted
+	VerdictWA  GradeVerdict = "WA"  // Wrong Answer
+	VerdictTLE GradeVerdict = "TLE" // Time Limit Exceeded
+	VerdictMLE GradeVerdict = "MLE" // Memory Limit Exceeded
+	VerdictRE  GradeVerdict = "RE"  // Runtime Error
+	VerdictCE  GradeVerdict = "CE"  // Compilation Error
+)
+
+// GradeCaseResult is the outcome of running the graded program against a single GradeCase.
+type GradeCaseResult struct {
+	Name       string
+	Verdict    GradeVerdict
+	Stdout     string
+	Stderr     string
+	ExecTimeMs int64
+}
+
+// GradeResult is what Grade returns: an overall Verdict (AC only if every case is AC, otherwise
+// the first non-AC verdict encountered in case order) plus one GradeCaseResult per GradeCase.
+type GradeResult struct {
+	Verdict       GradeVerdict
+	CompileTimeMs int64
+	Cases         []GradeCaseResult
+}
+
+// checkerFunc compares a case's actual stdout against its expected stdout.
+type checkerFunc func(ctx context.Context, stdout, expected string) (bool, error)
+
+// checkerFor returns the checkerFunc req.Checker selects, defaulting to CheckerTrimmed.
+func (e *CodeExecutor) checkerFor(req GradeRequest) checkerFunc {
+	switch req.Checker {
+	case CheckerExact:
+		return func(_ context.Context, stdout, expected string) (bool, error) {
+			return stdout == expected, nil
+		}
+	case CheckerWhitespaceInsensitive:
+		return func(_ context.Context, stdout, expected string) (bool, error) {
+			return normalizeWhitespace(stdout) == normalizeWhitespace(expected), nil
+		}
+	case CheckerFloatTolerance:
+		tolerance := req.FloatTolerance
+		return func(_ context.Context, stdout, expected string) (bool, error) {
+			return floatTokensMatch(stdout, expected, tolerance), nil
+		}
+	case CheckerCustom:
+		return func(ctx context.Context, stdout, expected string) (bool, error) {
+			return e.runCustomChecker(ctx, req, stdout, expected)
+		}
+	default: // "" and CheckerTrimmed
+		return func(_ context.Context, stdout, expected string) (bool, error) {
+			return strings.TrimSpace(stdout) == strings.TrimSpace(expected), nil
+		}
+	}
+}
+
+func normalizeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// floatTokensMatch compares stdout and expected token-by-token (split on whitespace), parsing
+// each pair as a float and allowing up to tolerance of difference; tokens that don't parse as
+// floats (e.g. a trailing word) fall back to exact string comparison.
+func floatTokensMatch(stdout, expected string, tolerance float64) bool {
+	actualTokens := strings.Fields(stdout)
+	expectedTokens := strings.Fields(expected)
+	if len(actualTokens) != len(expectedTokens) {
+		return false
+	}
+	for i, token := range actualTokens {
+		a, errA := strconv.ParseFloat(token, 64)
+		b, errB := strconv.ParseFloat(expectedTokens[i], 64)
+		if errA != nil || errB != nil {
+			if token != expectedTokens[i] {
+				return false
+			}
+			continue
+		}
+		if math.Abs(a-b) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// runCustomChecker runs req.CheckerCommand in req.CheckerImage (or imagePrefix+"checker" by
+// default), with the case's actual/expected stdout written to /code/actual.txt and
+// /code/expected.txt. A zero exit means the case passes, matching the convention judge-style
+// special checkers (e.g. testlib's checker.cpp) use.
+func (e *CodeExecutor) runCustomChecker(ctx context.Context, req GradeRequest, stdout, expected string) (bool, error) {
+	if !isContainerBackend(e.backend) {
+		return false, fmt.Errorf("custom checker requires a container-based backend, got %q", e.backend)
+	}
+
+	checkDir, err := ioutil.TempDir("", "aggiecode-checker-")
+	if err != nil {
+		return false, fmt.Errorf("failed to create checker directory: %w", err)
+	}
+	defer os.RemoveAll(checkDir)
+
+	if err := ioutil.WriteFile(filepath.Join(checkDir, "actual.txt"), []byte(stdout), 0644); err != nil {
+		return false, fmt.Errorf("failed to write checker input: %w", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(checkDir, "expected.txt"), []byte(expected), 0644); err != nil {
+		return false, fmt.Errorf("failed to write checker input: %w", err)
+	}
+
+	image := req.CheckerImage
+	if image == "" {
+		image = e.imagePrefix + "checker"
+	}
+
+	spec := ContainerSpec{
+		ImageName: image,
+		Cmd:       []string{"/bin/sh", "-c", req.CheckerCommand},
+		TempDir:   checkDir,
+		Memory:    DefaultMemoryLimit,
+		NanoCPUs:  int64(DefaultCPULimit * 1e9),
+		PidsLimit: DefaultPidsLimit,
+	}
+
+	handle, err := e.runtimeBackend.CreateAndStart(ctx, spec)
+	if err != nil {
+		return false, fmt.Errorf("checker container failed to start: %w", err)
+	}
+	defer e.runtimeBackend.Cleanup(context.Background(), handle)
+
+	exitCode, _, err := e.runtimeBackend.Wait(ctx, handle)
+	if err != nil {
+		return false, fmt.Errorf("error waiting for checker container: %w", err)
+	}
+	return exitCode == 0, nil
+}
+
+// Grade compiles req.Code once (reusing the same compileCache executeCompiled populates) and
+// runs it against each of req.Cases concurrently, up to req.Parallelism at a time, reporting a
+// judge-style verdict per case instead of ExecuteBatch's plain pass/fail.
+func (e *CodeExecutor) Grade(ctx context.Context, req GradeRequest) (GradeResult, error) {
+	if e.fallbackMode {
+		return e.mockExecutor.Grade(ctx, req)
+	}
+	if len(req.Cases) == 0 {
+		return GradeResult{}, fmt.Errorf("Grade requires at least one test case")
+	}
+
+	profile := profileFor(req.Language)
+	imageName, supported := SupportedLanguages[req.Language]
+	if !supported {
+		return GradeResult{}, ExecutionError{Type: "unsupported_language", Message: fmt.Sprintf("unsupported language: %s", req.Language)}
+	}
+	if e.imagePrefix != "" {
+		imageName = e.imagePrefix + imageName
+	}
+
+	tempDir, err := ioutil.TempDir("", fmt.Sprintf("aggiecode-%s-grade-", req.Language))
+	if err != nil {
+		return GradeResult{}, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filename, err := e.writeCodeFile(tempDir, req.Language, req.Code)
+	if err != nil {
+		return GradeResult{}, fmt.Errorf("failed to write code file: %w", err)
+	}
+	if err := writeBatchFiles(tempDir, req.Files); err != nil {
+		return GradeResult{}, fmt.Errorf("failed to write submission files: %w", err)
+	}
+
+	result := GradeResult{Verdict: VerdictAC}
+
+	compiled := LanguageCompilers[req.Language] && isContainerBackend(e.backend)
+	var filesToCopy []string
+	if compiled {
+		filesToCopy = compileArtifacts[req.Language]
+
+		key := compileCacheKey(req.Language, req.Code, imageName)
+		if !e.compileCache.Get(key, tempDir, filesToCopy) {
+			compileResult, err := e.runCompileStep(ctx, req.Language, filename, tempDir, imageName, profile)
+			result.CompileTimeMs = compileResult.timeMs
+			if err != nil {
+				return result, err
+			}
+			if compileResult.exitCode != 0 {
+				result.Verdict = VerdictCE
+				cases := make([]GradeCaseResult, len(req.Cases))
+				for i, tc := range req.Cases {
+					cases[i] = GradeCaseResult{Name: tc.Name, Verdict: VerdictCE, Stderr: compileResult.stderr}
+				}
+				result.Cases = cases
+				return result, nil
+			}
+			// A cache-write failure doesn't fail the grade; the artifacts are still in tempDir
+			// for this run, they just won't be cached for the next submission.
+			e.compileCache.Put(key, tempDir, filesToCopy)
+		}
+	} else {
+		filesToCopy = append([]string{filepath.Base(filename)}, batchFilePaths(req.Files)...)
+	}
+
+	parallelism := req.Parallelism
+	if parallelism <= 0 {
+		parallelism = 4
+	}
+	if parallelism > e.concurrentLimit {
+		parallelism = e.concurrentLimit
+	}
+
+	check := e.checkerFor(req)
+
+	results := make([]GradeCaseResult, len(req.Cases))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, tc := range req.Cases {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, tc GradeCase) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = e.runGradeCase(ctx, req.Language, imageName, tempDir, filesToCopy, compiled, profile, tc, check)
+		}(i, tc)
+	}
+	wg.Wait()
+
+	result.Cases = results
+	for _, r := range results {
+		if r.Verdict != VerdictAC {
+			result.Verdict = r.Verdict
+			break
+		}
+	}
+	return result, nil
+}
+
+// batchFilePaths returns each BatchFile's Path, for copying alongside the main code file into a
+// per-case directory.
+func batchFilePaths(files []BatchFile) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return paths
+}
+
+// runGradeCase runs the already-compiled (or, for interpreted languages, already-written)
+// program from sourceDir against a single GradeCase, in its own per-case directory so concurrent
+// cases never race over a shared stdin file.
+func (e *CodeExecutor) runGradeCase(ctx context.Context, language, imageName, sourceDir string, filesToCopy []string, compiled bool, profile LanguageProfile, tc GradeCase, check checkerFunc) GradeCaseResult {
+	result := GradeCaseResult{Name: tc.Name}
+
+	caseDir, err := ioutil.TempDir("", fmt.Sprintf("aggiecode-%s-case-", language))
+	if err != nil {
+		result.Verdict = VerdictRE
+		result.Stderr = fmt.Sprintf("failed to create case directory: %v", err)
+		return result
+	}
+	defer os.RemoveAll(caseDir)
+
+	for _, rel := range filesToCopy {
+		if err := copyIntoDir(sourceDir, caseDir, rel); err != nil {
+			result.Verdict = VerdictRE
+			result.Stderr = fmt.Sprintf("failed to prepare case: %v", err)
+			return result
+		}
+	}
+
+	var stdinFile string
+	if tc.Stdin != "" {
+		stdinFile = filepath.Join(caseDir, "input.txt")
+		if err := ioutil.WriteFile(stdinFile, []byte(tc.Stdin), 0644); err != nil {
+			result.Verdict = VerdictRE
+			result.Stderr = fmt.Sprintf("failed to write stdin: %v", err)
+			return result
+		}
+	}
+
+	timeout := profile.RunTimeout
+	if tc.TimeoutMs > 0 {
+		timeout = time.Duration(tc.TimeoutMs) * time.Millisecond
+	}
+	if timeout > MaxExecutionTime {
+		timeout = MaxExecutionTime
+	}
+
+	memory := profile.Memory
+	memorySwap := profile.MemorySwap
+	if tc.MemoryBytes > 0 {
+		memory = tc.MemoryBytes
+		memorySwap = tc.MemoryBytes // disable swap when a case overrides memory, for deterministic MLE detection
+	}
+
+	var cmd []string
+	if compiled {
+		cmd = buildRunCommand(filepath.Base(stdinFile), language)
+	} else {
+		cmd = e.buildCommand(filesToCopy[0], filepath.Base(stdinFile), language)
+	}
+
+	spec := ContainerSpec{
+		ImageName:    imageName,
+		Cmd:          cmd,
+		TempDir:      caseDir,
+		Memory:       memory,
+		MemorySwap:   memorySwap,
+		NanoCPUs:     profile.NanoCPUs,
+		PidsLimit:    profile.PidsLimit,
+		TmpfsSize:    profile.TmpfsSize,
+		Ulimits:      profile.Ulimits,
+		ReadOnlyCode: true,
+	}
+
+	caseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	handle, err := e.runtimeBackend.CreateAndStart(caseCtx, spec)
+	if err != nil {
+		if caseCtx.Err() == context.DeadlineExceeded {
+			result.Verdict = VerdictTLE
+			return result
+		}
+		result.Verdict = VerdictRE
+		result.Stderr = err.Error()
+		return result
+	}
+	defer e.runtimeBackend.Cleanup(context.Background(), handle)
+
+	exitCode, oomKilled, err := e.runtimeBackend.Wait(caseCtx, handle)
+	result.ExecTimeMs = time.Since(start).Milliseconds()
+	if err != nil {
+		if caseCtx.Err() == context.DeadlineExceeded {
+			result.Verdict = VerdictTLE
+			return result
+		}
+		result.Verdict = VerdictRE
+		result.Stderr = err.Error()
+		return result
+	}
+	if oomKilled {
+		result.Verdict = VerdictMLE
+		return result
+	}
+
+	stdout, stderr, err := e.runtimeBackend.Logs(context.Background(), handle)
+	if err != nil {
+		result.Verdict = VerdictRE
+		result.Stderr = err.Error()
+		return result
+	}
+	result.Stdout = stdout
+	result.Stderr = stderr
+
+	if exitCode != 0 {
+		result.Verdict = VerdictRE
+		return result
+	}
+
+	ok, err := check(ctx, stdout, tc.ExpectedStdout)
+	if err != nil {
+		result.Verdict = VerdictRE
+		result.Stderr = err.Error()
+		return result
+	}
+	if ok {
+		result.Verdict = VerdictAC
+	} else {
+		result.Verdict = VerdictWA
+	}
+	return result
+}
+
+// copyIntoDir copies srcDir/relPath to destDir/relPath, creating any parent directories relPath
+// implies. Artifacts are written with the executable bit so compiled binaries stay runnable.
+func copyIntoDir(srcDir, destDir, relPath string) error {
+	data, err := ioutil.ReadFile(filepath.Join(srcDir, relPath))
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(destDir, relPath)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dest, data, 0755)
+}
+
+// Grade is MockExecutor's fallback implementation: it delegates each case to Execute (no real
+// compile step) and compares trimmed stdout, same as runBatch does for ExecuteBatch.
+func (m *MockExecutor) Grade(ctx context.Context, req GradeRequest) (GradeResult, error) {
+	if len(req.Cases) == 0 {
+		return GradeResult{}, fmt.Errorf("Grade requires at least one test case")
+	}
+
+	results := make([]GradeCaseResult, len(req.Cases))
+	for i, tc := range req.Cases {
+		caseReq := ExecutionRequest{Language: req.Language, Code: req.Code, Stdin: tc.Stdin, Files: req.Files}
+		if tc.TimeoutMs > 0 {
+			caseReq.Timeout = time.Duration(tc.TimeoutMs) * time.Millisecond
+		}
+
+		execResult, err := m.Execute(ctx, caseReq)
+		cr := GradeCaseResult{Name: tc.Name, Stdout: execResult.Stdout, Stderr: execResult.Stderr, ExecTimeMs: execResult.ExecTimeMs}
+		switch {
+		case err != nil:
+			if execErr, ok := err.(ExecutionError); ok {
+				switch execErr.Type {
+				case "timeout":
+					cr.Verdict = VerdictTLE
+				case "memory_limit":
+					cr.Verdict = VerdictMLE
+				default:
+					cr.Verdict = VerdictRE
+				}
+			} else {
+				cr.Verdict = VerdictRE
+			}
+		case execResult.Error != "":
+			cr.Verdict = VerdictRE
+		case strings.TrimSpace(execResult.Stdout) == strings.TrimSpace(tc.ExpectedStdout):
+			cr.Verdict = VerdictAC
+		default:
+			cr.Verdict = VerdictWA
+		}
+		results[i] = cr
+	}
+
+	result := GradeResult{Verdict: VerdictAC, Cases: results}
+	for _, r := range results {
+		if r.Verdict != VerdictAC {
+			result.Verdict = r.Verdict
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package executor
+
+import (
+	"context"
+	"io"
+)
+
+// ResizeSpec is a single terminal resize event for an interactive execution.
+type ResizeSpec struct {
+	Rows uint
+	Cols uint
+}
+
+// InteractiveSession is a live, attached container session: stdin/stdout/stderr are already
+// being copied to/from the streams passed to Attach by the time it returns, so callers only
+// need to forward resize events and wait for the program to exit.
+type InteractiveSession interface {
+	// Resize notifies the container's TTY of a new size.
+	Resize(ctx context.Context, spec ResizeSpec) error
+	// Wait blocks until the attached program exits or ctx is canceled.
+	Wait(ctx context.Context) (ContainerResult, error)
+	// Close releases the attached connection and the underlying container.
+	Close()
+}
+
+// InteractiveRuntimeBackend is an optional RuntimeBackend capability: backends that can
+// allocate a TTY and attach stdin/stdout/stderr live (currently Docker/gVisor) implement it so
+// CodeExecutor.ExecuteInteractive can support REPL-style programs that prompt mid-execution,
+// something the stdin-file model Execute uses can't handle.
+type InteractiveRuntimeBackend interface {
+	RuntimeBackend
+	Attach(ctx context.Context, spec ContainerSpec, stdin io.Reader, stdout, stderr io.Writer) (InteractiveSession, error)
+}
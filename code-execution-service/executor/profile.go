@@ -0,0 +1,88 @@
+package executor
+
+import "time"
+
+// LanguageProfile carries the resource limits and timeouts for one SupportedLanguages entry.
+// Interpreted languages get away with the conservative defaults; compiled languages get more
+// memory/CPU headroom (a compiler is heavier than the program it produces) and a separate
+// CompileTimeout, since compilation and execution have very different expected durations.
+type LanguageProfile struct {
+	Memory     int64 // bytes
+	MemorySwap int64 // bytes; see ContainerSpec.MemorySwap
+	NanoCPUs   int64
+	PidsLimit  int64
+	TmpfsSize  int64 // bytes; 0 mounts no extra tmpfs
+	Ulimits    []Ulimit
+
+	// CompileTimeout bounds the compile step for LanguageCompilers languages; unused otherwise.
+	CompileTimeout time.Duration
+	// RunTimeout is the default execution timeout when a request doesn't specify one, before
+	// the MaxExecutionTime cap is applied.
+	RunTimeout time.Duration
+}
+
+// LanguageProfiles holds the resource profile for each SupportedLanguages entry. Compiled
+// languages (see LanguageCompilers) get more memory/CPU and a dedicated CompileTimeout;
+// interpreted languages use the package-level defaults that predate this map.
+var LanguageProfiles = map[string]LanguageProfile{
+	"python": {
+		Memory:     DefaultMemoryLimit,
+		MemorySwap: DefaultMemoryLimit,
+		NanoCPUs:   int64(DefaultCPULimit * 1e9),
+		PidsLimit:  DefaultPidsLimit,
+		RunTimeout: DefaultExecutionTime,
+	},
+	"javascript": {
+		Memory:     DefaultMemoryLimit,
+		MemorySwap: DefaultMemoryLimit,
+		NanoCPUs:   int64(DefaultCPULimit * 1e9),
+		PidsLimit:  DefaultPidsLimit,
+		RunTimeout: DefaultExecutionTime,
+	},
+	"cpp": {
+		Memory:         512 * 1024 * 1024,
+		MemorySwap:     512 * 1024 * 1024,
+		NanoCPUs:       int64(2 * 1e9),
+		PidsLimit:      100,
+		TmpfsSize:      64 * 1024 * 1024,
+		CompileTimeout: 20 * time.Second,
+		RunTimeout:     DefaultExecutionTime,
+	},
+	"java": {
+		Memory:     512 * 1024 * 1024,
+		MemorySwap: 512 * 1024 * 1024,
+		NanoCPUs:   int64(2 * 1e9),
+		PidsLimit:  150, // the JVM itself runs several housekeeping threads before user code starts
+		TmpfsSize:  64 * 1024 * 1024,
+		Ulimits: []Ulimit{
+			{Name: "nofile", Soft: 1024, Hard: 1024},
+		},
+		CompileTimeout: 20 * time.Second,
+		RunTimeout:     DefaultExecutionTime,
+	},
+	"go": {
+		Memory:         512 * 1024 * 1024,
+		MemorySwap:     512 * 1024 * 1024,
+		NanoCPUs:       int64(2 * 1e9),
+		PidsLimit:      100,
+		TmpfsSize:      64 * 1024 * 1024,
+		CompileTimeout: 20 * time.Second,
+		RunTimeout:     DefaultExecutionTime,
+	},
+}
+
+// profileFor returns language's LanguageProfile, falling back to the package-level Default*
+// constants for a language with no entry (e.g. one added to SupportedLanguages without a
+// matching profile yet).
+func profileFor(language string) LanguageProfile {
+	if profile, ok := LanguageProfiles[language]; ok {
+		return profile
+	}
+	return LanguageProfile{
+		Memory:     DefaultMemoryLimit,
+		MemorySwap: DefaultMemoryLimit,
+		NanoCPUs:   int64(DefaultCPULimit * 1e9),
+		PidsLimit:  DefaultPidsLimit,
+		RunTimeout: DefaultExecutionTime,
+	}
+}
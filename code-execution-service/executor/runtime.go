@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Supported values for ExecutorConfig.RuntimeEngine / the RUNTIME_ENGINE env var. These select
+// how CodeExecutor runs a container for the "docker" and "gvisor" SandboxBackend values;
+// Firecracker and Wasm never reach a RuntimeBackend at all (see sandbox.go).
+const (
+	EngineDocker      = "docker"
+	EngineContainerd  = "containerd"
+	EngineGVisor      = "gvisor"
+	EngineSingularity = "singularity"
+)
+
+// ContainerSpec describes a single container run, independent of which RuntimeBackend executes
+// it. All fields mirror the resource limits and isolation the Docker path has always enforced:
+// no network, a read-only rootfs, and explicit memory/CPU/PID ceilings.
+type ContainerSpec struct {
+	ImageName string
+	Cmd       []string
+	TempDir   string // bind-mounted read-write at /code
+	Memory    int64
+	// MemorySwap mirrors docker run's --memory-swap: set equal to Memory to disable swap
+	// entirely (the default LanguageProfiles do this, for deterministic OOM detection), 0 to
+	// leave the daemon's default (typically 2x Memory).
+	MemorySwap int64
+	NanoCPUs   int64
+	PidsLimit  int64
+	// TmpfsSize, if non-zero, mounts a size-bounded tmpfs at /tmp instead of relying on
+	// whatever tmpfs (or disk) the image provides, so compilers that spill to /tmp are held to
+	// the same per-language ceiling as /code.
+	TmpfsSize int64
+	Ulimits   []Ulimit
+	// ReadOnlyCode mounts TempDir read-only instead of read-write. The compile/run split for
+	// LanguageCompilers languages sets this for the run step, once the compile step has already
+	// produced everything the run step needs: a run container has no business rewriting /code.
+	ReadOnlyCode bool
+}
+
+// Ulimit is a single POSIX resource limit to apply inside the container, e.g. a cap on open
+// file descriptors for languages whose runtime opens far more of them than a script needs.
+type Ulimit struct {
+	Name string
+	Soft int64
+	Hard int64
+}
+
+// ContainerResult is what Wait/Logs report once a container has exited.
+type ContainerResult struct {
+	ExitCode  int64
+	OOMKilled bool
+	Stdout    string
+	Stderr    string
+}
+
+// RuntimeBackend runs a single container to completion and reports what it produced. It is
+// deliberately the smallest interface that covers createAndStartContainer, getContainerLogs,
+// and cleanupContainer's combined contract, so tests can swap in an in-process fake without a
+// real container runtime.
+type RuntimeBackend interface {
+	// CreateAndStart creates and starts a container for spec, returning an opaque handle.
+	CreateAndStart(ctx context.Context, spec ContainerSpec) (handle string, err error)
+	// Wait blocks until the container identified by handle exits or ctx is canceled.
+	Wait(ctx context.Context, handle string) (exitCode int64, oomKilled bool, err error)
+	// Logs returns the container's complete, demuxed stdout and stderr.
+	Logs(ctx context.Context, handle string) (stdout, stderr string, err error)
+	// Cleanup stops and removes the container identified by handle. Errors are neither
+	// returned nor logged, matching the fire-and-forget cleanup the Docker path has always
+	// done in a deferred call.
+	Cleanup(ctx context.Context, handle string)
+}
+
+// StreamingRuntimeBackend is an optional capability: backends that can follow container logs
+// live (currently just Docker/gVisor) implement it so CodeExecutor.ExecuteStream can push
+// output to a StreamSink as it's produced instead of buffering it until exit.
+type StreamingRuntimeBackend interface {
+	RuntimeBackend
+	Stream(ctx context.Context, spec ContainerSpec, sink StreamSink) (ContainerResult, error)
+}
+
+// PoolableRuntimeBackend is an optional capability: backends that can keep a container alive
+// across multiple executions (currently just Docker/gVisor) implement it so WarmPool can reuse
+// an already-started container's in-image supervisor instead of paying CreateAndStart on every
+// request.
+type PoolableRuntimeBackend interface {
+	RuntimeBackend
+	// Prewarm creates and starts a container running spec.Cmd (the image's supervisor
+	// entrypoint) and keeps it open for later Exec calls, returning an opaque handle.
+	Prewarm(ctx context.Context, spec ContainerSpec) (handle string, err error)
+	// Exec sends one piece of code (and optional stdin) to the supervisor already running in
+	// handle's container and returns what it produced, without restarting the container.
+	Exec(ctx context.Context, handle string, code, stdin string, timeout time.Duration) (stdout, stderr string, exitCode int64, err error)
+	// DiscardWarm tears down a pooled container that's exceeded its reuse budget or whose
+	// supervisor connection failed, instead of returning it to the pool.
+	DiscardWarm(handle string)
+}
+
+// newRuntimeBackend constructs the RuntimeBackend for engine, validating it has what it needs
+// (a reachable Docker daemon, a containerd socket, the singularity binary) up front so a
+// misconfigured engine fails at startup rather than on the first request.
+func newRuntimeBackend(engine string) (RuntimeBackend, error) {
+	switch engine {
+	case EngineDocker:
+		return newDockerRuntimeBackend("")
+	case EngineGVisor:
+		return newDockerRuntimeBackend("runsc")
+	case EngineContainerd:
+		return newContainerdRuntimeBackend()
+	case EngineSingularity:
+		return newSingularityRuntimeBackend()
+	default:
+		return nil, fmt.Errorf("unknown runtime engine: %s", engine)
+	}
+}
@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/oci"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// containerdNamespace isolates AggieCode's containers from anything else sharing the
+// containerd socket (k8s's containers, other tenants).
+const containerdNamespace = "aggiecode"
+
+// containerdRuntimeBackend talks directly to containerd's gRPC socket instead of going
+// through dockerd, for deployments that run containerd bare (no Docker daemon in the loop).
+type containerdRuntimeBackend struct {
+	client *containerd.Client
+}
+
+// containerdHandle is the live state behind a RuntimeBackend handle string.
+type containerdHandle struct {
+	container containerd.Container
+	task      containerd.Task
+	stdout    *bytes.Buffer
+	stderr    *bytes.Buffer
+}
+
+var containerdHandles = newHandleRegistry[*containerdHandle]()
+
+// newContainerdRuntimeBackend connects to the containerd socket named by CONTAINERD_ADDRESS,
+// defaulting to the standard /run/containerd/containerd.sock.
+func newContainerdRuntimeBackend() (*containerdRuntimeBackend, error) {
+	address := containerdSocketAddress()
+	client, err := containerd.New(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to containerd at %s: %w", address, err)
+	}
+	return &containerdRuntimeBackend{client: client}, nil
+}
+
+func (b *containerdRuntimeBackend) CreateAndStart(ctx context.Context, spec ContainerSpec) (string, error) {
+	ctx = namespaces.WithNamespace(ctx, containerdNamespace)
+
+	image, err := b.client.Pull(ctx, spec.ImageName, containerd.WithPullUnpack)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull image %q: %w", spec.ImageName, err)
+	}
+
+	id := fmt.Sprintf("aggiecode-%s", randomID())
+	container, err := b.client.NewContainer(ctx, id,
+		containerd.WithImage(image),
+		containerd.WithNewSnapshot(id+"-snapshot", image),
+		containerd.WithNewSpec(
+			oci.WithImageConfig(image),
+			oci.WithProcessArgs(spec.Cmd...),
+			oci.WithProcessCwd("/code"),
+			oci.WithMounts([]specs.Mount{codeMount(spec.TempDir, spec.ReadOnlyCode)}),
+			oci.WithMemoryLimit(uint64(spec.Memory)),
+			oci.WithPidsLimit(spec.PidsLimit),
+			oci.WithRootFSReadonly(),
+			oci.WithLinuxNamespace(specs.LinuxNamespace{Type: specs.NetworkNamespace}),
+		),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	task, err := container.NewTask(ctx, cio.NewCreator(cio.WithStreams(nil, &stdout, &stderr)))
+	if err != nil {
+		container.Delete(ctx)
+		return "", fmt.Errorf("failed to create task: %w", err)
+	}
+
+	if err := task.Start(ctx); err != nil {
+		task.Delete(ctx)
+		container.Delete(ctx)
+		return "", fmt.Errorf("failed to start task: %w", err)
+	}
+
+	containerdHandles.put(id, &containerdHandle{container: container, task: task, stdout: &stdout, stderr: &stderr})
+	return id, nil
+}
+
+func (b *containerdRuntimeBackend) Wait(ctx context.Context, handle string) (int64, bool, error) {
+	ctx = namespaces.WithNamespace(ctx, containerdNamespace)
+
+	h, ok := containerdHandles.get(handle)
+	if !ok {
+		return 0, false, fmt.Errorf("unknown containerd handle: %s", handle)
+	}
+
+	exitCh, err := h.task.Wait(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	select {
+	case status := <-exitCh:
+		code, _, err := status.Result()
+		if err != nil {
+			return 0, false, err
+		}
+		// containerd's OOM notification surfaces through the task's cgroup events rather than
+		// the exit status; a 137 (SIGKILL) exit on a memory-bound run is the closest analogue
+		// to the OOMKilled flag Docker reports directly.
+		oomKilled := code == 137
+		return int64(code), oomKilled, nil
+	case <-ctx.Done():
+		return 0, false, ctx.Err()
+	}
+}
+
+func (b *containerdRuntimeBackend) Logs(_ context.Context, handle string) (string, string, error) {
+	h, ok := containerdHandles.get(handle)
+	if !ok {
+		return "", "", fmt.Errorf("unknown containerd handle: %s", handle)
+	}
+	return h.stdout.String(), h.stderr.String(), nil
+}
+
+func (b *containerdRuntimeBackend) Cleanup(ctx context.Context, handle string) {
+	ctx = namespaces.WithNamespace(ctx, containerdNamespace)
+
+	h, ok := containerdHandles.take(handle)
+	if !ok {
+		return
+	}
+	h.task.Kill(ctx, 9)
+	h.task.Delete(ctx)
+	h.container.Delete(ctx, containerd.WithSnapshotCleanup)
+}
+
+// containerdSocketAddress reads CONTAINERD_ADDRESS, falling back to containerd's conventional
+// default socket path.
+func containerdSocketAddress() string {
+	if addr := os.Getenv("CONTAINERD_ADDRESS"); addr != "" {
+		return addr
+	}
+	return "/run/containerd/containerd.sock"
+}
+
+// codeMount binds tempDir at /code (read-only when readOnly is set), matching the Docker path's
+// bind mount.
+func codeMount(tempDir string, readOnly bool) specs.Mount {
+	mode := "rw"
+	if readOnly {
+		mode = "ro"
+	}
+	return specs.Mount{
+		Destination: "/code",
+		Type:        "bind",
+		Source:      tempDir,
+		Options:     []string{"rbind", mode},
+	}
+}
+
+// randomID returns a short random hex string for naming containers/snapshots.
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
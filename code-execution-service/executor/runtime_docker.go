@@ -0,0 +1,384 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	units "github.com/docker/go-units"
+)
+
+// dockerRuntimeBackend drives containers through the Docker API. It also serves the gVisor
+// engine: ociRuntime is "" for plain Docker and "runsc" to ask dockerd to start the container
+// under the runsc OCI runtime instead of the default one.
+type dockerRuntimeBackend struct {
+	client     *client.Client
+	ociRuntime string
+
+	warmMu    sync.Mutex
+	warmConns map[string]*dockerWarmConn // container ID -> its attached supervisor connection
+}
+
+// newDockerRuntimeBackend connects to the local Docker daemon and verifies it's reachable.
+func newDockerRuntimeBackend(ociRuntime string) (*dockerRuntimeBackend, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+	if _, err := cli.Ping(context.Background()); err != nil {
+		return nil, fmt.Errorf("docker daemon is not reachable: %w", err)
+	}
+	return &dockerRuntimeBackend{client: cli, ociRuntime: ociRuntime, warmConns: make(map[string]*dockerWarmConn)}, nil
+}
+
+func (b *dockerRuntimeBackend) containerConfig(spec ContainerSpec) (*container.Config, *container.HostConfig) {
+	mounts := []mount.Mount{
+		{
+			Type:     mount.TypeBind,
+			Source:   spec.TempDir,
+			Target:   "/code",
+			ReadOnly: spec.ReadOnlyCode,
+		},
+	}
+
+	config := &container.Config{
+		Image:      spec.ImageName,
+		Cmd:        spec.Cmd,
+		Tty:        false,
+		WorkingDir: "/code",
+	}
+
+	pidsLimit := spec.PidsLimit
+	ulimits := make([]*units.Ulimit, len(spec.Ulimits))
+	for i, u := range spec.Ulimits {
+		ulimits[i] = &units.Ulimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard}
+	}
+
+	hostConfig := &container.HostConfig{
+		Mounts:         mounts,
+		NetworkMode:    container.NetworkMode(DefaultNetworkPolicy),
+		ReadonlyRootfs: true,
+		Runtime:        b.ociRuntime,
+		Resources: container.Resources{
+			Memory:     spec.Memory,
+			MemorySwap: spec.MemorySwap,
+			NanoCPUs:   spec.NanoCPUs,
+			PidsLimit:  &pidsLimit,
+			Ulimits:    ulimits,
+		},
+	}
+
+	if spec.TmpfsSize > 0 {
+		hostConfig.Tmpfs = map[string]string{"/tmp": fmt.Sprintf("size=%d", spec.TmpfsSize)}
+	}
+
+	return config, hostConfig
+}
+
+func (b *dockerRuntimeBackend) CreateAndStart(ctx context.Context, spec ContainerSpec) (string, error) {
+	config, hostConfig := b.containerConfig(spec)
+
+	resp, err := b.client.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	if err != nil {
+		return "", err
+	}
+
+	if err := b.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return resp.ID, err // Return ID for cleanup, even though start failed
+	}
+
+	return resp.ID, nil
+}
+
+func (b *dockerRuntimeBackend) Wait(ctx context.Context, handle string) (int64, bool, error) {
+	statusCh, errCh := b.client.ContainerWait(ctx, handle, container.WaitConditionNotRunning)
+
+	var exitCode int64
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return 0, false, err
+		}
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	case <-ctx.Done():
+		return 0, false, ctx.Err()
+	}
+
+	oomKilled := false
+	if containerJSON, err := b.client.ContainerInspect(context.Background(), handle); err == nil && containerJSON.State != nil {
+		oomKilled = containerJSON.State.OOMKilled
+	}
+
+	return exitCode, oomKilled, nil
+}
+
+func (b *dockerRuntimeBackend) Logs(ctx context.Context, handle string) (string, string, error) {
+	reader, err := b.client.ContainerLogs(ctx, handle, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	defer reader.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, reader); err != nil {
+		return "", "", err
+	}
+
+	return stdout.String(), stderr.String(), nil
+}
+
+func (b *dockerRuntimeBackend) Cleanup(ctx context.Context, handle string) {
+	stopTimeout := 1 // 1 second timeout for stopping
+	if err := b.client.ContainerStop(ctx, handle, container.StopOptions{Timeout: &stopTimeout}); err != nil {
+		b.client.ContainerKill(ctx, handle, "SIGKILL")
+	}
+	b.client.ContainerRemove(ctx, handle, container.RemoveOptions{Force: true})
+}
+
+// Stream creates and starts a container the same way CreateAndStart does, but follows its logs
+// live and pushes each chunk to sink as it arrives instead of waiting for the container to exit.
+func (b *dockerRuntimeBackend) Stream(ctx context.Context, spec ContainerSpec, sink StreamSink) (ContainerResult, error) {
+	handle, err := b.CreateAndStart(ctx, spec)
+	if err != nil {
+		return ContainerResult{}, err
+	}
+	defer b.Cleanup(context.Background(), handle)
+
+	logsReader, err := b.client.ContainerLogs(ctx, handle, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err != nil {
+		return ContainerResult{}, fmt.Errorf("failed to attach to container logs: %w", err)
+	}
+	defer logsReader.Close()
+
+	copyDone := make(chan error, 1)
+	go func() {
+		_, copyErr := stdcopy.StdCopy(&sinkWriter{sink: sink, kind: "stdout"}, &sinkWriter{sink: sink, kind: "stderr"}, logsReader)
+		copyDone <- copyErr
+	}()
+
+	exitCode, oomKilled, err := b.Wait(ctx, handle)
+	if err != nil {
+		return ContainerResult{}, err
+	}
+
+	<-copyDone // make sure all buffered output has been delivered before the caller sends "exit"
+
+	return ContainerResult{ExitCode: exitCode, OOMKilled: oomKilled}, nil
+}
+
+// Attach creates and starts a container with a TTY allocated and stdin/stdout/stderr wired
+// live, for REPL-style programs that prompt mid-execution instead of just consuming a fixed
+// stdin file and producing output Logs can collect afterwards.
+func (b *dockerRuntimeBackend) Attach(ctx context.Context, spec ContainerSpec, stdin io.Reader, stdout, stderr io.Writer) (InteractiveSession, error) {
+	config, hostConfig := b.containerConfig(spec)
+	config.Tty = true
+	config.OpenStdin = true
+	config.StdinOnce = false
+	config.AttachStdin = true
+	config.AttachStdout = true
+	config.AttachStderr = true
+
+	resp, err := b.client.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	hijacked, err := b.client.ContainerAttach(ctx, resp.ID, container.AttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		b.client.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+		return nil, fmt.Errorf("failed to attach to container: %w", err)
+	}
+
+	if err := b.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		hijacked.Close()
+		b.client.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+		return nil, err
+	}
+
+	go io.Copy(hijacked.Conn, stdin)
+
+	// A TTY multiplexes stdout and stderr onto the single attached stream with no framing to
+	// tell them apart (the same reason docker run -t does this); everything is forwarded to
+	// stdout, matching what a real terminal attached to the container would show.
+	copyDone := make(chan struct{})
+	go func() {
+		io.Copy(stdout, hijacked.Reader)
+		close(copyDone)
+	}()
+
+	return &dockerInteractiveSession{backend: b, containerID: resp.ID, hijacked: hijacked, copyDone: copyDone}, nil
+}
+
+// dockerInteractiveSession is the InteractiveSession behind dockerRuntimeBackend.Attach.
+type dockerInteractiveSession struct {
+	backend     *dockerRuntimeBackend
+	containerID string
+	hijacked    types.HijackedResponse
+	copyDone    chan struct{}
+}
+
+func (s *dockerInteractiveSession) Resize(ctx context.Context, spec ResizeSpec) error {
+	return s.backend.client.ContainerResize(ctx, s.containerID, container.ResizeOptions{
+		Height: spec.Rows,
+		Width:  spec.Cols,
+	})
+}
+
+func (s *dockerInteractiveSession) Wait(ctx context.Context) (ContainerResult, error) {
+	exitCode, oomKilled, err := s.backend.Wait(ctx, s.containerID)
+	if err != nil {
+		return ContainerResult{}, err
+	}
+	<-s.copyDone // make sure all buffered output has been delivered before the caller reports exit
+	return ContainerResult{ExitCode: exitCode, OOMKilled: oomKilled}, nil
+}
+
+func (s *dockerInteractiveSession) Close() {
+	s.hijacked.Close()
+	s.backend.Cleanup(context.Background(), s.containerID)
+}
+
+// dockerWarmConn is the attached connection to one pooled container's in-image supervisor,
+// kept open across the container's entire pooled lifetime instead of per-request like Attach.
+type dockerWarmConn struct {
+	hijacked types.HijackedResponse
+	reader   *bufio.Reader // scans the supervisor's newline-delimited JSON response frames
+}
+
+// Prewarm creates and starts a pooled container running spec.Cmd (the image's supervisor
+// entrypoint) with a TTY attached the same way Attach does, and keeps the connection open so
+// later Exec calls can reuse it instead of paying ContainerCreate/ContainerStart again.
+func (b *dockerRuntimeBackend) Prewarm(ctx context.Context, spec ContainerSpec) (string, error) {
+	config, hostConfig := b.containerConfig(spec)
+	config.Tty = true
+	config.OpenStdin = true
+	config.StdinOnce = false
+	config.AttachStdin = true
+	config.AttachStdout = true
+	config.AttachStderr = true
+
+	resp, err := b.client.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	if err != nil {
+		return "", err
+	}
+
+	hijacked, err := b.client.ContainerAttach(ctx, resp.ID, container.AttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		b.client.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+		return "", fmt.Errorf("failed to attach to warm container: %w", err)
+	}
+
+	if err := b.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		hijacked.Close()
+		b.client.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+		return "", err
+	}
+
+	b.warmMu.Lock()
+	b.warmConns[resp.ID] = &dockerWarmConn{hijacked: hijacked, reader: bufio.NewReader(hijacked.Reader)}
+	b.warmMu.Unlock()
+
+	return resp.ID, nil
+}
+
+// warmRequest/warmResponse are the newline-delimited JSON frames exchanged with a pooled
+// container's supervisor over its attached TTY: one request frame per Exec call, answered with
+// exactly one response frame once the supervisor's child process has exited.
+type warmRequest struct {
+	Code      string `json:"code"`
+	Stdin     string `json:"stdin,omitempty"`
+	TimeoutMs int64  `json:"timeout_ms,omitempty"`
+}
+
+type warmResponse struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int64  `json:"exit_code"`
+}
+
+// Exec sends one request frame to the pooled container identified by handle and waits for its
+// response frame. A caller that gets an error here should not return the container to the pool:
+// a supervisor connection that didn't answer cleanly isn't safe to hand to the next request.
+func (b *dockerRuntimeBackend) Exec(ctx context.Context, handle, code, stdin string, timeout time.Duration) (string, string, int64, error) {
+	b.warmMu.Lock()
+	conn, ok := b.warmConns[handle]
+	b.warmMu.Unlock()
+	if !ok {
+		return "", "", 0, fmt.Errorf("no warm connection for container %s", handle)
+	}
+
+	frame, err := json.Marshal(warmRequest{Code: code, Stdin: stdin, TimeoutMs: timeout.Milliseconds()})
+	if err != nil {
+		return "", "", 0, fmt.Errorf("failed to encode warm request: %w", err)
+	}
+	if _, err := conn.hijacked.Conn.Write(append(frame, '\n')); err != nil {
+		return "", "", 0, fmt.Errorf("failed to send request to warm container: %w", err)
+	}
+
+	type readResult struct {
+		line string
+		err  error
+	}
+	lineCh := make(chan readResult, 1)
+	go func() {
+		line, err := conn.reader.ReadString('\n')
+		lineCh <- readResult{line, err}
+	}()
+
+	select {
+	case r := <-lineCh:
+		if r.err != nil {
+			return "", "", 0, fmt.Errorf("failed to read warm container response: %w", r.err)
+		}
+		var resp warmResponse
+		if err := json.Unmarshal([]byte(r.line), &resp); err != nil {
+			return "", "", 0, fmt.Errorf("malformed warm container response: %w", err)
+		}
+		return resp.Stdout, resp.Stderr, resp.ExitCode, nil
+	case <-ctx.Done():
+		return "", "", 0, ctx.Err()
+	}
+}
+
+// DiscardWarm closes a pooled container's supervisor connection and tears the container down,
+// for when it's exceeded its reuse budget or a failed Exec left its connection in an unknown
+// state.
+func (b *dockerRuntimeBackend) DiscardWarm(handle string) {
+	b.warmMu.Lock()
+	conn, ok := b.warmConns[handle]
+	delete(b.warmConns, handle)
+	b.warmMu.Unlock()
+
+	if ok {
+		conn.hijacked.Close()
+	}
+	b.Cleanup(context.Background(), handle)
+}
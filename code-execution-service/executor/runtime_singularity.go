@@ -0,0 +1,124 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// singularityRuntimeBackend runs code via the Singularity/Apptainer CLI instead of a daemon,
+// for HPC-style deployments where Docker and containerd aren't available (or aren't allowed)
+// on the compute nodes. This mirrors the RuntimeEngine: docker|singularity toggle Arvados
+// exposes for the same reason.
+type singularityRuntimeBackend struct {
+	binary string // "singularity" or "apptainer", whichever is on PATH
+}
+
+// singularityRun is the live state behind a RuntimeBackend handle string: singularity exec
+// has no separate create/start/wait like Docker, so CreateAndStart launches the process and
+// Wait blocks on it.
+type singularityRun struct {
+	cmd    *exec.Cmd
+	stdout *bytes.Buffer
+	stderr *bytes.Buffer
+	done   chan error
+}
+
+var singularityHandles = newHandleRegistry[*singularityRun]()
+
+// newSingularityRuntimeBackend looks for the singularity binary (falling back to apptainer,
+// its upstream successor) on PATH.
+func newSingularityRuntimeBackend() (*singularityRuntimeBackend, error) {
+	if _, err := exec.LookPath("singularity"); err == nil {
+		return &singularityRuntimeBackend{binary: "singularity"}, nil
+	}
+	if _, err := exec.LookPath("apptainer"); err == nil {
+		return &singularityRuntimeBackend{binary: "apptainer"}, nil
+	}
+	return nil, fmt.Errorf("runtime engine %q requires the singularity or apptainer binary on PATH", EngineSingularity)
+}
+
+func (b *singularityRuntimeBackend) CreateAndStart(ctx context.Context, spec ContainerSpec) (string, error) {
+	codeBind := fmt.Sprintf("%s:/code", spec.TempDir)
+	if spec.ReadOnlyCode {
+		codeBind += ":ro"
+	}
+	args := []string{
+		"exec",
+		"--containall",               // no host filesystem/env/PID leakage
+		"--net", "--network", "none", // no network
+		"--no-home",
+		"--writable-tmpfs",
+		"--bind", codeBind,
+		"--pwd", "/code",
+	}
+	if spec.Memory > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%d", spec.Memory))
+	}
+	if spec.PidsLimit > 0 {
+		args = append(args, "--pids-limit", fmt.Sprintf("%d", spec.PidsLimit))
+	}
+	args = append(args, "docker://"+spec.ImageName)
+	args = append(args, spec.Cmd...)
+
+	cmd := exec.CommandContext(ctx, b.binary, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start %s: %w", b.binary, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	id := fmt.Sprintf("aggiecode-%s", randomID())
+	singularityHandles.put(id, &singularityRun{cmd: cmd, stdout: &stdout, stderr: &stderr, done: done})
+	return id, nil
+}
+
+func (b *singularityRuntimeBackend) Wait(ctx context.Context, handle string) (int64, bool, error) {
+	run, ok := singularityHandles.get(handle)
+	if !ok {
+		return 0, false, fmt.Errorf("unknown singularity handle: %s", handle)
+	}
+
+	select {
+	case err := <-run.done:
+		if err == nil {
+			return 0, false, nil
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code := int64(exitErr.ExitCode())
+			// SIGKILL (128+9) is the closest signal a cgroup-enforced memory limit produces
+			// through a plain exec.Cmd exit status; Docker reports this explicitly instead.
+			return code, code == 137, nil
+		}
+		return 0, false, err
+	case <-ctx.Done():
+		if run.cmd.Process != nil {
+			run.cmd.Process.Kill()
+		}
+		return 0, false, ctx.Err()
+	}
+}
+
+func (b *singularityRuntimeBackend) Logs(_ context.Context, handle string) (string, string, error) {
+	run, ok := singularityHandles.get(handle)
+	if !ok {
+		return "", "", fmt.Errorf("unknown singularity handle: %s", handle)
+	}
+	return run.stdout.String(), run.stderr.String(), nil
+}
+
+func (b *singularityRuntimeBackend) Cleanup(_ context.Context, handle string) {
+	run, ok := singularityHandles.take(handle)
+	if !ok {
+		return
+	}
+	if run.cmd.Process != nil {
+		run.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,174 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWarmPoolMaxReuse is how many Execute calls a pooled container serves before WarmPool
+// recycles it for a fresh one, bounding how long any resource leak inside the supervisor or its
+// language runtime can accumulate.
+const DefaultWarmPoolMaxReuse = 50
+
+// pooledContainer is one warm, already-started container sitting idle in a WarmPool, waiting to
+// be claimed by Execute.
+type pooledContainer struct {
+	handle    string
+	tempDir   string // this container's /code bind mount; removed alongside the container on discard
+	createdAt time.Time
+	useCount  int
+}
+
+// WarmPool keeps up to size idle, already-started containers per language, each running the
+// image's in-image supervisor over an attached stdin/stdout stream (see dockerWarmConn), so
+// Execute can skip ContainerCreate+ContainerStart entirely on a pool hit. A miss — pool empty,
+// backend doesn't support pooling, or the supervisor connection turns out to be wedged — simply
+// falls back to Execute's normal from-scratch path; the pool is a latency optimization, never a
+// requirement for correctness.
+type WarmPool struct {
+	backend   PoolableRuntimeBackend
+	buildSpec func(language string) (ContainerSpec, bool) // resolves a language to its warm-container spec; false for unsupported languages
+	size      int
+	maxReuse  int           // 0 means unlimited
+	idleTTL   time.Duration // 0 means no limit
+
+	mu   sync.Mutex
+	idle map[string][]*pooledContainer
+}
+
+// newWarmPool constructs a WarmPool. maxReuse <= 0 disables the reuse cap; idleTTL <= 0 disables
+// the idle-time cap.
+func newWarmPool(backend PoolableRuntimeBackend, buildSpec func(string) (ContainerSpec, bool), size, maxReuse int, idleTTL time.Duration) *WarmPool {
+	return &WarmPool{
+		backend:   backend,
+		buildSpec: buildSpec,
+		size:      size,
+		maxReuse:  maxReuse,
+		idleTTL:   idleTTL,
+		idle:      make(map[string][]*pooledContainer),
+	}
+}
+
+// Prewarm fills language's pool up to size. It's meant to be called once per supported language
+// at startup (in its own goroutine, since it's best-effort and shouldn't delay the server coming
+// up) rather than per request.
+func (p *WarmPool) Prewarm(ctx context.Context, language string) {
+	spec, ok := p.buildSpec(language)
+	if !ok {
+		return
+	}
+	for i := 0; i < p.size; i++ {
+		p.addIdle(ctx, language, spec)
+	}
+}
+
+// addIdle starts one fresh warm container for language and adds it to the idle pool. Failures
+// are swallowed: a language whose image doesn't ship a supervisor, or a daemon that's briefly
+// unreachable, just means every Execute call for that language falls back to the cold path,
+// exactly as if the pool were empty.
+func (p *WarmPool) addIdle(ctx context.Context, language string, spec ContainerSpec) {
+	tempDir, err := ioutil.TempDir("", fmt.Sprintf("aggiecode-%s-warm-", language))
+	if err != nil {
+		return
+	}
+	spec.TempDir = tempDir
+
+	handle, err := p.backend.Prewarm(ctx, spec)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return
+	}
+
+	p.mu.Lock()
+	p.idle[language] = append(p.idle[language], &pooledContainer{handle: handle, tempDir: tempDir, createdAt: time.Now()})
+	p.mu.Unlock()
+}
+
+// discard tears down a pooled container and its tempDir, for when it's been recycled out of the
+// pool (reuse/idle limit hit) or its supervisor connection failed.
+func (p *WarmPool) discard(c *pooledContainer) {
+	p.backend.DiscardWarm(c.handle)
+	os.RemoveAll(c.tempDir)
+}
+
+// Acquire claims an idle warm container for language, transparently discarding (and trying
+// again) any that have sat idle past idleTTL. It reports ok=false on a pool miss — language has
+// no idle containers left — which is the caller's signal to fall back to the cold path.
+func (p *WarmPool) Acquire(language string) (*pooledContainer, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		containers := p.idle[language]
+		if len(containers) == 0 {
+			return nil, false
+		}
+		c := containers[len(containers)-1]
+		p.idle[language] = containers[:len(containers)-1]
+
+		if p.idleTTL > 0 && time.Since(c.createdAt) > p.idleTTL {
+			go p.discard(c)
+			continue
+		}
+		return c, true
+	}
+}
+
+// Release returns a used container to language's idle pool for its next Execute call, unless
+// it's hit maxReuse or the pool already has size idle containers, in which case it's discarded
+// and — best-effort — replaced so the pool stays topped up.
+func (p *WarmPool) Release(ctx context.Context, language string, c *pooledContainer, spec ContainerSpec) {
+	c.useCount++
+	if p.maxReuse > 0 && c.useCount >= p.maxReuse {
+		go p.discard(c)
+		go p.addIdle(ctx, language, spec)
+		return
+	}
+
+	p.mu.Lock()
+	full := len(p.idle[language]) >= p.size
+	if !full {
+		p.idle[language] = append(p.idle[language], c)
+	}
+	p.mu.Unlock()
+
+	if full {
+		go p.discard(c)
+	}
+}
+
+// Exec runs code (with optional stdin) against an already-warm container's supervisor.
+func (p *WarmPool) Exec(ctx context.Context, c *pooledContainer, code, stdin string, timeout time.Duration) (string, string, int64, error) {
+	return p.backend.Exec(ctx, c.handle, code, stdin, timeout)
+}
+
+// tryWarmExecute attempts req against a container from e.warmPool, reporting ok=false on a pool
+// miss or a failed exchange so Execute can fall back to its normal from-scratch path. Only
+// reached for interpreted languages with no extra Files: the supervisor protocol carries a
+// single code+stdin payload, not a multi-file submission or the compile/run split's artifacts.
+func (e *CodeExecutor) tryWarmExecute(ctx context.Context, req ExecutionRequest, startTime time.Time) (ExecutionResult, bool) {
+	c, ok := e.warmPool.Acquire(req.Language)
+	if !ok {
+		return ExecutionResult{}, false
+	}
+
+	spec, _ := e.warmPool.buildSpec(req.Language) // req.Language was already validated by SupportedLanguages above
+
+	stdout, stderr, exitCode, err := e.warmPool.Exec(ctx, c, req.Code, req.Stdin, req.Timeout)
+	if err != nil {
+		go e.warmPool.discard(c)
+		go e.warmPool.addIdle(context.Background(), req.Language, spec)
+		return ExecutionResult{}, false
+	}
+	e.warmPool.Release(context.Background(), req.Language, c, spec)
+
+	result := ExecutionResult{Stdout: stdout, Stderr: stderr, ExecTimeMs: time.Since(startTime).Milliseconds()}
+	if exitCode != 0 {
+		result.Error = fmt.Sprintf("Process exited with code %d", exitCode)
+	}
+	return result, true
+}
@@ -0,0 +1,121 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// wasmSandbox runs languages that compile to WASI (Python via python.wasm, JavaScript via a
+// QuickJS-WASI build) inside an in-process wazero runtime. There is no subprocess and no
+// container at all here, which makes it the cheapest backend to start but the one most
+// limited in which languages it can support.
+type wasmSandbox struct {
+	moduleDir string // holds one compiled module per language, e.g. "python.wasm"
+}
+
+// wasmInstance is the handle state for a single prepared module instantiation.
+type wasmInstance struct {
+	runtime  wazero.Runtime
+	module   []byte
+	argv     []string
+	stdin    []byte
+	tempDir  string
+	codePath string
+}
+
+var wasmHandles = newHandleRegistry[*wasmInstance]()
+
+// Prepare loads the compiled module for spec.Language from disk; the module itself isn't
+// instantiated until Run, since wazero instances aren't safely reusable across goroutines.
+func (s *wasmSandbox) Prepare(ctx context.Context, spec SandboxSpec) (SandboxHandle, error) {
+	modulePath := filepath.Join(s.moduleDir, spec.Language+".wasm")
+	module, err := os.ReadFile(modulePath)
+	if err != nil {
+		return SandboxHandle{}, fmt.Errorf("no wasm module for language %q: %w", spec.Language, err)
+	}
+
+	var stdin []byte
+	if spec.StdinFile != "" {
+		stdin, err = os.ReadFile(spec.StdinFile)
+		if err != nil {
+			return SandboxHandle{}, fmt.Errorf("failed to read stdin file: %w", err)
+		}
+	}
+
+	handle := SandboxHandle{ID: spec.Language + "-" + filepath.Base(spec.TempDir)}
+	wasmHandles.put(handle.ID, &wasmInstance{
+		module:   module,
+		argv:     []string{spec.Language, filepath.Base(spec.CodeFile)},
+		stdin:    stdin,
+		tempDir:  spec.TempDir,
+		codePath: spec.CodeFile,
+	})
+	return handle, nil
+}
+
+// Run instantiates the module with the code directory mounted read-only at "/code" and the
+// guest's stdin/stdout/stderr wired to in-memory buffers, then lets the WASI exit call
+// unwind the call stack the way a real process exit would.
+func (s *wasmSandbox) Run(ctx context.Context, handle SandboxHandle) (SandboxResult, error) {
+	inst, ok := wasmHandles.get(handle.ID)
+	if !ok {
+		return SandboxResult{}, fmt.Errorf("unknown wasm handle: %s", handle.ID)
+	}
+
+	runtime := wazero.NewRuntime(ctx)
+	defer runtime.Close(ctx)
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		return SandboxResult{}, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+
+	compiled, err := runtime.CompileModule(ctx, inst.module)
+	if err != nil {
+		return SandboxResult{}, fmt.Errorf("failed to compile wasm module: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	config := wazero.NewModuleConfig().
+		WithArgs(inst.argv...).
+		WithStdin(bytes.NewReader(inst.stdin)).
+		WithStdout(&stdout).
+		WithStderr(&stderr).
+		WithFSConfig(wazero.NewFSConfig().WithReadOnlyDirMount(inst.tempDir, "/code"))
+
+	exitCode := int64(0)
+	if _, err := runtime.InstantiateModule(ctx, compiled, config); err != nil {
+		if exitErr, ok := asExitError(err); ok {
+			exitCode = exitErr
+		} else if ctx.Err() != nil {
+			return SandboxResult{}, ctx.Err()
+		} else {
+			return SandboxResult{Stdout: stdout.String(), Stderr: stderr.String()}, fmt.Errorf("wasm execution failed: %w", err)
+		}
+	}
+
+	return SandboxResult{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: exitCode}, nil
+}
+
+// Destroy drops the handle; the wazero runtime created in Run is already closed by the time
+// Destroy is called, so there's nothing left to release here beyond the registry entry.
+func (s *wasmSandbox) Destroy(handle SandboxHandle) {
+	wasmHandles.take(handle.ID)
+}
+
+// asExitError unwraps wazero's sys.ExitError to recover the guest's exit code, so a Python
+// script calling sys.exit(1) maps to the same "non-zero exit" handling as every other backend.
+func asExitError(err error) (int64, bool) {
+	type exitCoder interface {
+		ExitCode() uint32
+	}
+	if ec, ok := err.(exitCoder); ok {
+		return int64(ec.ExitCode()), true
+	}
+	return 0, false
+}
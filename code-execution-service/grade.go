@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/IMMZEK/AggieCode/code-execution-service/executor"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// GradeHTTPRequest defines the structure for judge-style grading requests: one program run
+// against N independent test cases, each returning an AC/WA/TLE/MLE/RE/CE verdict instead of
+// ExecuteRequest.Tests' plain pass/fail.
+type GradeHTTPRequest struct {
+	Language string        `json:"language"`
+	Code     string        `json:"code"`
+	Files    []RequestFile `json:"files,omitempty"`
+	Cases    []RequestCase `json:"cases"`
+
+	Checker        string  `json:"checker,omitempty"`         // "trimmed" (default), "exact", "whitespace_insensitive", "float_tolerance", or "custom"
+	FloatTolerance float64 `json:"float_tolerance,omitempty"` // used when checker is "float_tolerance"
+	CheckerCommand string  `json:"checker_command,omitempty"` // used when checker is "custom"
+	CheckerImage   string  `json:"checker_image,omitempty"`   // used when checker is "custom"
+	Parallelism    int     `json:"parallelism,omitempty"`     // max concurrent cases; defaults to 4
+}
+
+// RequestCase is one test case to grade Code against.
+type RequestCase struct {
+	Name           string `json:"name"`
+	Stdin          string `json:"stdin,omitempty"`
+	ExpectedStdout string `json:"expected_stdout"`
+	TimeoutMs      int    `json:"timeout_ms,omitempty"`
+	MemoryBytes    int64  `json:"memory_bytes,omitempty"`
+}
+
+// GradeResponse is what gradeHandler returns.
+type GradeResponse struct {
+	Verdict       string              `json:"verdict"`
+	CompileTimeMs int64               `json:"compile_time_ms,omitempty"`
+	Cases         []GradeCaseResponse `json:"cases,omitempty"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// GradeCaseResponse is the outcome of one RequestCase, returned in GradeResponse.Cases.
+type GradeCaseResponse struct {
+	Name       string `json:"name"`
+	Verdict    string `json:"verdict"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExecTimeMs int64  `json:"execution_time_ms"`
+}
+
+// gradeHandler runs a program against a set of judge-style test cases through
+// codeExecutor.Grade, behind the same per-identity admission control as executeHandler since a
+// single grade request can spawn as many containers as it has cases.
+func gradeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	identity := admission.identityFor(r)
+	queueWait, admitErr := admission.Admit(r.Context(), identity)
+	if admitErr != nil {
+		retryAfter := "1"
+		if admitErr == ErrMaxWaitExceeded {
+			retryAfter = strconv.Itoa(int(admission.maxWait.Seconds()) + 1)
+		}
+		slog.Warn("Grade request rejected by admission control", "identity", identity, "error", admitErr, "queue_wait_ms", queueWait.Milliseconds())
+		w.Header().Set("Retry-After", retryAfter)
+		writeGradeResponse(w, http.StatusTooManyRequests, GradeResponse{Error: admitErr.Error()})
+		return
+	}
+
+	var req GradeHTTPRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		slog.Warn("Failed to decode grade request body", "error", err)
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Code == "" {
+		http.Error(w, "Missing 'code' field in request", http.StatusBadRequest)
+		return
+	}
+	if req.Language == "" {
+		http.Error(w, "Missing 'language' field in request", http.StatusBadRequest)
+		return
+	}
+	if len(req.Cases) == 0 {
+		http.Error(w, "Missing 'cases' field in request", http.StatusBadRequest)
+		return
+	}
+
+	slog.Info("Received grade request", "language", req.Language, "code_length", len(req.Code), "cases", len(req.Cases))
+
+	parentCtx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	go func() {
+		<-r.Context().Done()
+		cancel()
+	}()
+
+	gradeReq := buildGradeRequest(req)
+	result, err := codeExecutor.Grade(ctx, gradeReq)
+	resp := GradeResponse{
+		Verdict:       string(result.Verdict),
+		CompileTimeMs: result.CompileTimeMs,
+		Cases:         buildGradeCaseResponses(result.Cases),
+	}
+
+	statusCode := http.StatusOK
+	if err != nil {
+		slog.Error("Grading failed", "error", err, "language", req.Language)
+		if execErr, ok := err.(executor.ExecutionError); ok {
+			resp.Error = execErr.Message
+			if execErr.Type == "unsupported_language" {
+				statusCode = http.StatusBadRequest
+			} else {
+				statusCode = http.StatusInternalServerError
+			}
+		} else {
+			resp.Error = fmt.Sprintf("Grading error: %v", err)
+			statusCode = http.StatusInternalServerError
+		}
+	}
+
+	writeGradeResponse(w, statusCode, resp)
+}
+
+// buildGradeRequest converts a GradeHTTPRequest into the executor's internal GradeRequest.
+func buildGradeRequest(req GradeHTTPRequest) executor.GradeRequest {
+	files := make([]executor.BatchFile, len(req.Files))
+	for i, f := range req.Files {
+		files[i] = executor.BatchFile{Path: f.Path, Content: f.Content}
+	}
+
+	cases := make([]executor.GradeCase, len(req.Cases))
+	for i, c := range req.Cases {
+		cases[i] = executor.GradeCase{
+			Name:           c.Name,
+			Stdin:          c.Stdin,
+			ExpectedStdout: c.ExpectedStdout,
+			TimeoutMs:      c.TimeoutMs,
+			MemoryBytes:    c.MemoryBytes,
+		}
+	}
+
+	return executor.GradeRequest{
+		Language:       req.Language,
+		Code:           req.Code,
+		Files:          files,
+		Cases:          cases,
+		Checker:        executor.CheckerKind(req.Checker),
+		FloatTolerance: req.FloatTolerance,
+		CheckerCommand: req.CheckerCommand,
+		CheckerImage:   req.CheckerImage,
+		Parallelism:    req.Parallelism,
+	}
+}
+
+// buildGradeCaseResponses converts executor.GradeCaseResults into the HTTP-facing
+// GradeCaseResponse list.
+func buildGradeCaseResponses(cases []executor.GradeCaseResult) []GradeCaseResponse {
+	out := make([]GradeCaseResponse, len(cases))
+	for i, c := range cases {
+		out[i] = GradeCaseResponse{
+			Name:       c.Name,
+			Verdict:    string(c.Verdict),
+			Stdout:     c.Stdout,
+			Stderr:     c.Stderr,
+			ExecTimeMs: c.ExecTimeMs,
+		}
+	}
+	return out
+}
+
+// writeGradeResponse writes resp as a complete, non-chunked JSON body, the same way
+// writeExecuteResponse does for /api/execute.
+func writeGradeResponse(w http.ResponseWriter, statusCode int, resp GradeResponse) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		slog.Error("Failed to encode grade response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(body); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
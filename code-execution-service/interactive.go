@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/IMMZEK/AggieCode/code-execution-service/executor"
+	"github.com/gorilla/websocket"
+)
+
+// interactiveFrame is the WebSocket message both directions of executeInteractiveHandler
+// exchange: the client sends "stdin" and "resize" frames, the server sends "stdout", "stderr",
+// "exit", and "error" frames.
+type interactiveFrame struct {
+	Type     string `json:"type"`
+	Data     string `json:"data,omitempty"`
+	Rows     uint   `json:"rows,omitempty"`
+	Cols     uint   `json:"cols,omitempty"`
+	ExitCode int64  `json:"exit_code,omitempty"`
+}
+
+// executeInteractiveHandler upgrades to WebSocket and runs an interactive, TTY-attached
+// execution: the client's first message is an ExecuteRequest, after which "stdin" frames are
+// forwarded to the program's stdin and "resize" frames adjust its TTY size, while the server
+// streams "stdout"/"stderr" frames back until a final "exit" or "error" frame.
+func executeInteractiveHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Error("Failed to upgrade to websocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	var req ExecuteRequest
+	if err := conn.ReadJSON(&req); err != nil {
+		conn.WriteJSON(interactiveFrame{Type: "error", Data: err.Error()})
+		return
+	}
+	if req.Code == "" || req.Language == "" {
+		conn.WriteJSON(interactiveFrame{Type: "error", Data: "missing 'code' or 'language' field"})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	stdinReader, stdinWriter := io.Pipe()
+	resize := make(chan executor.ResizeSpec, 4)
+
+	var writeMu sync.Mutex
+	stdout := &wsFrameWriter{conn: conn, mu: &writeMu, frameType: "stdout"}
+	stderr := &wsFrameWriter{conn: conn, mu: &writeMu, frameType: "stderr"}
+
+	go readInteractiveFrames(conn, stdinWriter, resize, cancel)
+
+	execReq := buildExecutionRequest(req)
+	err = codeExecutor.ExecuteInteractive(ctx, execReq, stdinReader, stdout, stderr, resize)
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	if err != nil {
+		slog.Error("Interactive execution failed", "error", err, "language", req.Language)
+		conn.WriteJSON(interactiveFrame{Type: "error", Data: err.Error()})
+		return
+	}
+	conn.WriteJSON(interactiveFrame{Type: "exit"})
+}
+
+// readInteractiveFrames reads client frames for the lifetime of the connection, writing
+// "stdin" frames to stdinWriter and "resize" frames to resize. It returns (closing stdinWriter
+// and resize, and canceling the execution) once the client disconnects or sends a frame that
+// doesn't deserialize as JSON.
+func readInteractiveFrames(conn *websocket.Conn, stdinWriter *io.PipeWriter, resize chan<- executor.ResizeSpec, cancel context.CancelFunc) {
+	defer stdinWriter.Close()
+	defer close(resize)
+	for {
+		var frame interactiveFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			cancel()
+			return
+		}
+		switch frame.Type {
+		case "stdin":
+			if _, err := stdinWriter.Write([]byte(frame.Data)); err != nil {
+				return
+			}
+		case "resize":
+			select {
+			case resize <- executor.ResizeSpec{Rows: frame.Rows, Cols: frame.Cols}:
+			default:
+				// The execution has already stopped consuming resizes (or the buffer is
+				// momentarily full); drop the event rather than block the read loop.
+			}
+		}
+	}
+}
+
+// wsFrameWriter adapts a websocket connection to io.Writer, wrapping each Write in an
+// interactiveFrame of frameType. mu is shared with the connection's other writer (and the
+// handler's own final exit/error frame) since gorilla/websocket connections aren't safe for
+// concurrent writers.
+type wsFrameWriter struct {
+	conn      *websocket.Conn
+	mu        *sync.Mutex
+	frameType string
+}
+
+func (w *wsFrameWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.conn.WriteJSON(interactiveFrame{Type: w.frameType, Data: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
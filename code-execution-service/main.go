@@ -8,31 +8,87 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/IMMZEK/AggieCode/code-execution-service/executor"
+	"github.com/IMMZEK/AggieCode/code-execution-service/metrics"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer emits the spans wrapping each execution. It uses whatever TracerProvider the process
+// is configured with (the OTel SDK's global no-op by default), so this works without requiring
+// an exporter to be wired up.
+var tracer = otel.Tracer("github.com/IMMZEK/AggieCode/code-execution-service")
+
 // ExecuteRequest defines the structure for code execution requests.
 type ExecuteRequest struct {
-	Language string `json:"language"`
-	Code     string `json:"code"`
-	Stdin    string `json:"stdin,omitempty"`   // Optional standard input
-	Timeout  int    `json:"timeout,omitempty"` // Optional timeout in seconds
+	Language string        `json:"language"`
+	Code     string        `json:"code"`
+	Stdin    string        `json:"stdin,omitempty"`   // Optional standard input
+	Timeout  int           `json:"timeout,omitempty"` // Optional timeout in seconds
+	Files    []RequestFile `json:"files,omitempty"`   // Optional additional files for multi-file submissions
+	Tests    []RequestTest `json:"tests,omitempty"`   // When non-empty, run Code as a test harness instead of a single execution
+}
+
+// RequestFile is one additional file in a multi-file submission, written alongside Code.
+type RequestFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// RequestTest is one test-harness case to run Code against when ExecuteRequest.Tests is set.
+type RequestTest struct {
+	Name           string `json:"name"`
+	Stdin          string `json:"stdin,omitempty"`
+	ExpectedStdout string `json:"expected_stdout"`
+	TimeoutMs      int    `json:"timeout_ms,omitempty"`
 }
 
 // ExecuteResponse defines the structure for code execution responses.
 type ExecuteResponse struct {
-	Stdout          string `json:"stdout"`
-	Stderr          string `json:"stderr"`
-	Error           string `json:"error,omitempty"`      // For execution or setup errors
-	ErrorType       string `json:"error_type,omitempty"` // Type of error (timeout, memory_limit, etc.)
-	ExecutionTimeMs int64  `json:"execution_time_ms"`
+	Stdout          string             `json:"stdout"`
+	Stderr          string             `json:"stderr"`
+	Error           string             `json:"error,omitempty"`      // For execution or setup errors
+	ErrorType       string             `json:"error_type,omitempty"` // Type of error (timeout, memory_limit, etc.)
+	ExecutionTimeMs int64              `json:"execution_time_ms"`
+	CompileTimeMs   int64              `json:"compile_time_ms,omitempty"` // Set for LanguageCompilers languages only
+	RunTimeMs       int64              `json:"run_time_ms,omitempty"`     // Set for LanguageCompilers languages only
+	QueueWaitMs     int64              `json:"queue_wait_ms,omitempty"`   // Time spent waiting on admission control, if any
+	TestResults     []ResponseTestCase `json:"test_results,omitempty"`    // Populated instead of Stdout/Stderr when the request included Tests
+}
+
+// ResponseTestCase is the outcome of one RequestTest, returned in ExecuteResponse.TestResults.
+type ResponseTestCase struct {
+	Name       string `json:"name"`
+	Pass       bool   `json:"pass"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExecTimeMs int64  `json:"execution_time_ms"`
+	ErrorType  string `json:"error_type,omitempty"`
 }
 
 // Global executor service
 var codeExecutor executor.CodeExecutionService
 
+// admission enforces per-identity rate limits ahead of executeHandler; see admission.go.
+var admission *AdmissionControl
+
+// writeTimeout mirrors the http.Server's WriteTimeout so executeHandler can race its own
+// deadline against it. writeTimeoutMargin is how long before that deadline we give up on
+// the executor and flush a structured timeout response instead of letting net/http sever
+// the connection mid-write. Both are vars (not consts) so tests can shrink them.
+var (
+	writeTimeout       = 35 * time.Second
+	writeTimeoutMargin = 3 * time.Second
+)
+
 func main() {
 	// Basic structured logging
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
@@ -40,9 +96,14 @@ func main() {
 
 	// Initialize the executor with configuration from environment variables
 	executorConfig := executor.ExecutorConfig{
-		ImagePrefix:     os.Getenv("IMAGE_PREFIX"),
-		ConcurrentLimit: getConcurrentLimitFromEnv(),
-		DefaultTimeout:  getDefaultTimeoutFromEnv(),
+		ImagePrefix:      os.Getenv("IMAGE_PREFIX"),
+		ConcurrentLimit:  getConcurrentLimitFromEnv(),
+		DefaultTimeout:   getDefaultTimeoutFromEnv(),
+		SandboxBackend:   os.Getenv("SANDBOX_BACKEND"),
+		RuntimeEngine:    os.Getenv("RUNTIME_ENGINE"),
+		WarmPoolSize:     getIntFromEnv("WARM_POOL_SIZE", 0),
+		WarmPoolMaxReuse: getIntFromEnv("WARM_POOL_MAX_REUSE", 0),
+		WarmPoolIdleTTL:  time.Duration(getIntFromEnv("WARM_POOL_IDLE_TTL_SECONDS", 0)) * time.Second,
 	}
 
 	var err error
@@ -52,6 +113,9 @@ func main() {
 		os.Exit(1)
 	}
 
+	admission = admissionControlFromEnv()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8081" // Default port for the CES
@@ -60,13 +124,17 @@ func main() {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/execute", executeHandler)
+	mux.HandleFunc("/api/execute/stream", executeStreamHandler)
+	mux.HandleFunc("/api/execute/interactive", executeInteractiveHandler)
+	mux.HandleFunc("/api/execute/grade", gradeHandler)
 	mux.HandleFunc("/health", healthCheckHandler)
+	mux.Handle("/metrics", metrics.Handler())
 
 	server := &http.Server{
 		Addr:         ":" + port,
 		Handler:      mux,
 		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 35 * time.Second, // Longer timeout to account for maximum execution time
+		WriteTimeout: writeTimeout, // Longer timeout to account for maximum execution time
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -100,6 +168,23 @@ func getConcurrentLimitFromEnv() int {
 	return limit
 }
 
+// getIntFromEnv reads name as a non-negative int, falling back to def if it's unset, invalid, or
+// negative.
+func getIntFromEnv(name string, def int) int {
+	valueStr := os.Getenv(name)
+	if valueStr == "" {
+		return def
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < 0 {
+		slog.Warn("Invalid environment value, using default", "name", name, "value", valueStr, "default", def)
+		return def
+	}
+
+	return value
+}
+
 // Helper function to get default timeout from environment
 func getDefaultTimeoutFromEnv() time.Duration {
 	timeoutStr := os.Getenv("DEFAULT_TIMEOUT")
@@ -129,6 +214,23 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	identity := admission.identityFor(r)
+	queueWait, admitErr := admission.Admit(r.Context(), identity)
+	if admitErr != nil {
+		retryAfter := "1"
+		if admitErr == ErrMaxWaitExceeded {
+			retryAfter = strconv.Itoa(int(admission.maxWait.Seconds()) + 1)
+		}
+		slog.Warn("Request rejected by admission control", "identity", identity, "error", admitErr, "queue_wait_ms", queueWait.Milliseconds())
+		w.Header().Set("Retry-After", retryAfter)
+		writeExecuteResponse(w, http.StatusTooManyRequests, ExecuteResponse{
+			ErrorType:   "admission_rejected",
+			Error:       admitErr.Error(),
+			QueueWaitMs: queueWait.Milliseconds(),
+		})
+		return
+	}
+
 	var req ExecuteRequest
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields() // Prevent unexpected fields
@@ -155,26 +257,15 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 		"code_length", len(req.Code),
 		"timeout", req.Timeout)
 
-	// Convert timeout to duration
-	var timeout time.Duration
-	if req.Timeout > 0 {
-		timeout = time.Duration(req.Timeout) * time.Second
-		// Cap at the maximum allowed timeout
-		if timeout > executor.MaxExecutionTime {
-			timeout = executor.MaxExecutionTime
-		}
-	}
-
 	// Create an execution request for the executor
-	execReq := executor.ExecutionRequest{
-		Language: req.Language,
-		Code:     req.Code,
-		Stdin:    req.Stdin,
-		Timeout:  timeout,
-	}
+	execReq := buildExecutionRequest(req)
+
+	// Propagate a traceparent from the caller, if present, so this execution's span joins
+	// the caller's trace instead of starting a new one.
+	parentCtx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
 
 	// Create a context with request-scoped cancellation
-	ctx, cancel := context.WithCancel(r.Context())
+	ctx, cancel := context.WithCancel(parentCtx)
 	defer cancel()
 
 	// Handle client disconnection
@@ -183,8 +274,55 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 		cancel()             // Cancel our execution context
 	}()
 
+	// respondOnce guards against both a normal completion and the write-timeout guard below
+	// trying to write the response body; only the first writer wins.
+	var respondOnce sync.Once
+
+	// If the executor is still running when the server's WriteTimeout is about to fire,
+	// net/http would otherwise sever the connection mid-write. Beat it to the punch: cancel
+	// the execution context and flush a fully-formed timeout response first.
+	timeoutMargin := writeTimeoutMargin
+	deadline := writeTimeout - timeoutMargin
+	if deadline <= 0 {
+		deadline = writeTimeout
+	}
+	timeoutTimer := time.AfterFunc(deadline, func() {
+		cancel()
+		respondOnce.Do(func() {
+			writeExecuteResponse(w, http.StatusRequestTimeout, ExecuteResponse{
+				ErrorType: "timeout",
+				Error:     "execution did not complete before the server write timeout",
+			})
+		})
+	})
+	defer timeoutTimer.Stop()
+
+	ctx, span := startExecutionSpan(ctx, req)
+	defer span.End()
+
+	if len(req.Tests) > 0 {
+		batchResult, err := codeExecutor.ExecuteBatch(ctx, execReq)
+		resp := ExecuteResponse{
+			TestResults: buildTestResults(batchResult),
+			QueueWaitMs: queueWait.Milliseconds(),
+		}
+		statusCode := http.StatusOK
+		if err != nil {
+			slog.Error("Batch execution failed", "error", err, "language", req.Language)
+			resp.Error = fmt.Sprintf("Execution error: %v", err)
+			statusCode = http.StatusInternalServerError
+			span.SetStatus(codes.Error, err.Error())
+		}
+		respondOnce.Do(func() {
+			writeExecuteResponse(w, statusCode, resp)
+		})
+		return
+	}
+
 	// Execute the code
+	stopRunning := metrics.ExecutionStarted()
 	result, err := codeExecutor.Execute(ctx, execReq)
+	stopRunning()
 
 	// Create the response
 	resp := ExecuteResponse{
@@ -192,6 +330,9 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 		Stderr:          result.Stderr,
 		Error:           result.Error,
 		ExecutionTimeMs: result.ExecTimeMs,
+		CompileTimeMs:   result.CompileTimeMs,
+		RunTimeMs:       result.RunTimeMs,
+		QueueWaitMs:     queueWait.Milliseconds(),
 	}
 
 	// Handle specific error types
@@ -230,11 +371,222 @@ func executeHandler(w http.ResponseWriter, r *http.Request) {
 		"error_type", resp.ErrorType,
 		"has_error", resp.Error != "")
 
+	metrics.RecordExecution(req.Language, resp.ErrorType, time.Duration(result.ExecTimeMs)*time.Millisecond)
+	span.SetAttributes(attribute.String("error_type", resp.ErrorType))
+	if resp.ErrorType != "" {
+		span.SetStatus(codes.Error, resp.Error)
+	}
+
+	respondOnce.Do(func() {
+		writeExecuteResponse(w, statusCode, resp)
+	})
+}
+
+// startExecutionSpan starts the span wrapping a single call to codeExecutor.Execute or
+// ExecuteBatch, tagged with the request attributes a downstream trace viewer would want to
+// filter or group by.
+func startExecutionSpan(ctx context.Context, req ExecuteRequest) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "codeExecutor.Execute",
+		trace.WithAttributes(
+			attribute.String("language", req.Language),
+			attribute.Int("code_length", len(req.Code)),
+			attribute.Int("timeout_seconds", req.Timeout),
+		),
+	)
+}
+
+// writeExecuteResponse writes resp as a complete, non-chunked JSON body. It sets an
+// explicit Content-Length (rather than letting net/http chunk the response) so that once
+// the bytes are handed to ResponseWriter.Write, net/http's WriteTimeout tearing the
+// connection down afterwards can no longer truncate what the client already received.
+func writeExecuteResponse(w http.ResponseWriter, statusCode int, resp ExecuteResponse) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		slog.Error("Failed to encode response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
 	w.WriteHeader(statusCode)
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		slog.Error("Failed to encode response", "error", err)
+	if _, err := w.Write(body); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+// wsUpgrader upgrades execute-stream requests that ask for the WebSocket variant.
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// executeStreamHandler upgrades to either Server-Sent Events (default) or WebSocket
+// (when the client sends "Upgrade: websocket") and streams stdout/stderr chunks as the
+// executor produces them, finishing with an "exit" or "error" frame.
+func executeStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		executeStreamWebSocket(w, r)
+		return
+	}
+	executeStreamSSE(w, r)
+}
+
+func executeStreamSSE(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ExecuteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Code == "" || req.Language == "" {
+		http.Error(w, "Missing 'code' or 'language' field in request", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	execReq := buildExecutionRequest(req)
+	sink := &sseSink{w: w, flusher: flusher}
+	if err := codeExecutor.ExecuteStream(ctx, execReq, sink); err != nil {
+		slog.Error("Streaming execution failed", "error", err, "language", req.Language)
+		sink.Send(executor.StreamChunk{Kind: "error", ErrorType: "internal", Data: err.Error()})
+	}
+}
+
+// sseSink writes StreamChunks as Server-Sent Events data frames.
+type sseSink struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	mu      sync.Mutex
+}
+
+func (s *sseSink) Send(chunk executor.StreamChunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func executeStreamWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Error("Failed to upgrade to websocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	var req ExecuteRequest
+	if err := conn.ReadJSON(&req); err != nil {
+		conn.WriteJSON(executor.StreamChunk{Kind: "error", ErrorType: "invalid_request", Data: err.Error()})
+		return
+	}
+	if req.Code == "" || req.Language == "" {
+		conn.WriteJSON(executor.StreamChunk{Kind: "error", ErrorType: "invalid_request", Data: "missing 'code' or 'language' field"})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	execReq := buildExecutionRequest(req)
+	sink := &wsSink{conn: conn}
+	if err := codeExecutor.ExecuteStream(ctx, execReq, sink); err != nil {
+		slog.Error("Streaming execution failed", "error", err, "language", req.Language)
+		sink.Send(executor.StreamChunk{Kind: "error", ErrorType: "internal", Data: err.Error()})
+	}
+}
+
+// wsSink writes StreamChunks as JSON frames over a websocket connection.
+type wsSink struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (s *wsSink) Send(chunk executor.StreamChunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.WriteJSON(chunk)
+}
+
+// buildExecutionRequest converts an ExecuteRequest into the executor's internal
+// ExecutionRequest, capping the timeout the same way executeHandler does.
+func buildExecutionRequest(req ExecuteRequest) executor.ExecutionRequest {
+	var timeout time.Duration
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+		if timeout > executor.MaxExecutionTime {
+			timeout = executor.MaxExecutionTime
+		}
+	}
+
+	files := make([]executor.BatchFile, len(req.Files))
+	for i, f := range req.Files {
+		files[i] = executor.BatchFile{Path: f.Path, Content: f.Content}
+	}
+
+	tests := make([]executor.TestCase, len(req.Tests))
+	for i, t := range req.Tests {
+		tests[i] = executor.TestCase{
+			Name:           t.Name,
+			Stdin:          t.Stdin,
+			ExpectedStdout: t.ExpectedStdout,
+			TimeoutMs:      t.TimeoutMs,
+		}
+	}
+
+	return executor.ExecutionRequest{
+		Language: req.Language,
+		Code:     req.Code,
+		Stdin:    req.Stdin,
+		Timeout:  timeout,
+		Files:    files,
+		Tests:    tests,
+	}
+}
+
+// buildTestResults converts an executor.BatchResult into the HTTP-facing ResponseTestCase list.
+func buildTestResults(result executor.BatchResult) []ResponseTestCase {
+	out := make([]ResponseTestCase, len(result.TestResults))
+	for i, tr := range result.TestResults {
+		out[i] = ResponseTestCase{
+			Name:       tr.Name,
+			Pass:       tr.Pass,
+			Stdout:     tr.Stdout,
+			Stderr:     tr.Stderr,
+			ExecTimeMs: tr.ExecTimeMs,
+			ErrorType:  tr.ErrorType,
+		}
 	}
+	return out
 }
 
 // healthCheckHandler returns a basic health check response
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/IMMZEK/AggieCode/code-execution-service/executor"
 )
@@ -16,12 +18,19 @@ import (
 type TestExecutor struct{}
 
 // Execute mocks the code execution process without actually using Docker
-func (m *TestExecutor) Execute(_ context.Context, req executor.ExecutionRequest) (executor.ExecutionResult, error) {
+func (m *TestExecutor) Execute(ctx context.Context, req executor.ExecutionRequest) (executor.ExecutionResult, error) {
 	// Simulate different responses based on language and code content
 	result := executor.ExecutionResult{
 		ExecTimeMs: 10, // Fixed execution time for predictability in tests
 	}
 
+	// Simulate an execution that never finishes on its own, so tests can exercise the
+	// write-timeout guard in executeHandler; it only returns once its context is canceled.
+	if strings.Contains(req.Code, "slow_forever") {
+		<-ctx.Done()
+		return result, ctx.Err()
+	}
+
 	// Simulate a timeout error if requested in the code
 	if strings.Contains(req.Code, "timeout") {
 		return result, executor.ExecutionError{
@@ -86,11 +95,123 @@ func (m *TestExecutor) Execute(_ context.Context, req executor.ExecutionRequest)
 	return result, nil
 }
 
+// ExecuteStream mocks streaming execution by running Execute and replaying the result
+// as stdout/stderr chunks followed by an exit chunk.
+func (m *TestExecutor) ExecuteStream(ctx context.Context, req executor.ExecutionRequest, sink executor.StreamSink) error {
+	result, err := m.Execute(ctx, req)
+	if err != nil {
+		errType := "runtime"
+		if execErr, ok := err.(executor.ExecutionError); ok {
+			errType = execErr.Type
+		}
+		return sink.Send(executor.StreamChunk{Kind: "error", Data: err.Error(), ErrorType: errType})
+	}
+	if result.Stdout != "" {
+		if err := sink.Send(executor.StreamChunk{Kind: "stdout", Data: result.Stdout}); err != nil {
+			return err
+		}
+	}
+	if result.Stderr != "" {
+		if err := sink.Send(executor.StreamChunk{Kind: "stderr", Data: result.Stderr}); err != nil {
+			return err
+		}
+	}
+	return sink.Send(executor.StreamChunk{Kind: "exit", ExecTimeMs: result.ExecTimeMs})
+}
+
+// ExecuteBatch mocks test-harness execution by delegating to Execute once per test case.
+func (m *TestExecutor) ExecuteBatch(ctx context.Context, req executor.ExecutionRequest) (executor.BatchResult, error) {
+	results := make([]executor.TestResult, len(req.Tests))
+	for i, tc := range req.Tests {
+		caseReq := req
+		caseReq.Stdin = tc.Stdin
+		caseReq.Tests = nil
+		result, err := m.Execute(ctx, caseReq)
+		tr := executor.TestResult{
+			Name:       tc.Name,
+			Stdout:     result.Stdout,
+			Stderr:     result.Stderr,
+			ExecTimeMs: result.ExecTimeMs,
+		}
+		if err != nil {
+			if execErr, ok := err.(executor.ExecutionError); ok {
+				tr.ErrorType = execErr.Type
+			} else {
+				tr.ErrorType = "runtime"
+			}
+		} else {
+			tr.Pass = strings.TrimSpace(result.Stdout) == strings.TrimSpace(tc.ExpectedStdout)
+		}
+		results[i] = tr
+	}
+	return executor.BatchResult{TestResults: results}, nil
+}
+
+// Grade mocks judge-style grading by delegating to Execute once per case and comparing trimmed
+// stdout, the same way ExecuteBatch does for plain pass/fail.
+func (m *TestExecutor) Grade(ctx context.Context, req executor.GradeRequest) (executor.GradeResult, error) {
+	results := make([]executor.GradeCaseResult, len(req.Cases))
+	for i, tc := range req.Cases {
+		execReq := executor.ExecutionRequest{Language: req.Language, Code: req.Code, Stdin: tc.Stdin}
+		result, err := m.Execute(ctx, execReq)
+		cr := executor.GradeCaseResult{Name: tc.Name, Stdout: result.Stdout, Stderr: result.Stderr, ExecTimeMs: result.ExecTimeMs}
+		switch {
+		case err != nil:
+			if execErr, ok := err.(executor.ExecutionError); ok && execErr.Type == "timeout" {
+				cr.Verdict = executor.VerdictTLE
+			} else if ok && execErr.Type == "memory_limit" {
+				cr.Verdict = executor.VerdictMLE
+			} else {
+				cr.Verdict = executor.VerdictRE
+			}
+		case result.Error != "":
+			cr.Verdict = executor.VerdictRE
+		case strings.TrimSpace(result.Stdout) == strings.TrimSpace(tc.ExpectedStdout):
+			cr.Verdict = executor.VerdictAC
+		default:
+			cr.Verdict = executor.VerdictWA
+		}
+		results[i] = cr
+	}
+
+	verdict := executor.VerdictAC
+	for _, r := range results {
+		if r.Verdict != executor.VerdictAC {
+			verdict = r.Verdict
+			break
+		}
+	}
+	return executor.GradeResult{Verdict: verdict, Cases: results}, nil
+}
+
+// ExecuteInteractive mocks interactive execution by running Execute and writing its result to
+// stdout/stderr as if it had arrived over an attached session; resize frames are discarded.
+func (m *TestExecutor) ExecuteInteractive(ctx context.Context, req executor.ExecutionRequest, _ io.Reader, stdout, stderr io.Writer, resize <-chan executor.ResizeSpec) error {
+	go func() {
+		for range resize {
+		}
+	}()
+
+	result, err := m.Execute(ctx, req)
+	if err != nil {
+		io.WriteString(stderr, err.Error())
+		return err
+	}
+	io.WriteString(stdout, result.Stdout)
+	io.WriteString(stderr, result.Stderr)
+	return nil
+}
+
 func setupTestServer() *httptest.Server {
 	// Use the mock executor instead of the real one for tests
 	codeExecutor = &TestExecutor{}
+	// Generous limits so admission control never interferes with tests that aren't about it
+	admission = NewAdmissionControl(100000, 1000, 1000, time.Second)
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/execute", executeHandler)
+	mux.HandleFunc("/api/execute/stream", executeStreamHandler)
+	mux.HandleFunc("/api/execute/interactive", executeInteractiveHandler)
+	mux.HandleFunc("/api/execute/grade", gradeHandler)
 	mux.HandleFunc("/health", healthCheckHandler)
 	return httptest.NewServer(mux)
 }
@@ -364,6 +485,178 @@ func TestExecuteHandler_UnsupportedLanguage(t *testing.T) {
 	}
 }
 
+func TestExecuteHandler_WriteTimeoutGuard(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	// Shrink the write-timeout deadline so the guard fires well before the test would
+	// otherwise hang waiting on an execution that never completes on its own.
+	origTimeout, origMargin := writeTimeout, writeTimeoutMargin
+	writeTimeout = 100 * time.Millisecond
+	writeTimeoutMargin = 80 * time.Millisecond
+	defer func() {
+		writeTimeout, writeTimeoutMargin = origTimeout, origMargin
+	}()
+
+	requestBody := `{"language":"python","code":"# slow_forever"}`
+	req, _ := http.NewRequest("POST", server.URL+"/api/execute", bytes.NewBufferString(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("Expected status RequestTimeout, got %v", resp.Status)
+	}
+	if cl := resp.Header.Get("Content-Length"); cl == "" {
+		t.Error("Expected an explicit Content-Length header on the timeout response")
+	}
+
+	var result ExecuteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Expected a decodable JSON body, got error: %v", err)
+	}
+	if result.ErrorType != "timeout" {
+		t.Errorf("Expected error_type 'timeout', got: %s", result.ErrorType)
+	}
+}
+
+func TestExecuteHandler_TestHarness(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	requestBody := `{"language":"python","code":"print('Hello from Python!')","tests":[` +
+		`{"name":"matches","expected_stdout":"Hello from Python!"},` +
+		`{"name":"mismatches","expected_stdout":"something else"}]}`
+	req, _ := http.NewRequest("POST", server.URL+"/api/execute", bytes.NewBufferString(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status OK, got %v", resp.Status)
+	}
+
+	var result ExecuteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(result.TestResults) != 2 {
+		t.Fatalf("Expected 2 test results, got %d", len(result.TestResults))
+	}
+	if !result.TestResults[0].Pass {
+		t.Errorf("Expected first test case to pass, got: %+v", result.TestResults[0])
+	}
+	if result.TestResults[1].Pass {
+		t.Errorf("Expected second test case to fail, got: %+v", result.TestResults[1])
+	}
+}
+
+func TestGradeHandler_ValidRequest(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	requestBody := `{"language":"python","code":"print('Hello from Python!')","cases":[` +
+		`{"name":"matches","expected_stdout":"Hello from Python!"},` +
+		`{"name":"mismatches","expected_stdout":"something else"}]}`
+	req, _ := http.NewRequest("POST", server.URL+"/api/execute/grade", bytes.NewBufferString(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status OK, got %v", resp.Status)
+	}
+
+	var result GradeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(result.Cases) != 2 {
+		t.Fatalf("Expected 2 case results, got %d", len(result.Cases))
+	}
+	if result.Cases[0].Verdict != "AC" {
+		t.Errorf("Expected first case to be AC, got: %+v", result.Cases[0])
+	}
+	if result.Cases[1].Verdict != "WA" {
+		t.Errorf("Expected second case to be WA, got: %+v", result.Cases[1])
+	}
+	if result.Verdict != "WA" {
+		t.Errorf("Expected overall verdict WA, got: %s", result.Verdict)
+	}
+}
+
+func TestGradeHandler_MissingCases(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	requestBody := `{"language":"python","code":"print('hi')"}`
+	req, _ := http.NewRequest("POST", server.URL+"/api/execute/grade", bytes.NewBufferString(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status BadRequest, got %v", resp.Status)
+	}
+}
+
+func TestExecuteStreamHandler_SSE(t *testing.T) {
+	server := setupTestServer()
+	defer server.Close()
+
+	requestBody := `{"language":"python","code":"print('Hello, world!')"}`
+	req, _ := http.NewRequest("POST", server.URL+"/api/execute/stream", bytes.NewBufferString(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status OK, got %v", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, got %s", ct)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+
+	if !strings.Contains(string(body), "Hello from Python!") {
+		t.Errorf("Expected stream to contain stdout chunk, got: %s", body)
+	}
+	if !strings.Contains(string(body), `"Kind":"exit"`) {
+		t.Errorf("Expected stream to end with an exit chunk, got: %s", body)
+	}
+}
+
 func TestHealthCheck(t *testing.T) {
 	server := setupTestServer()
 	defer server.Close()
@@ -0,0 +1,60 @@
+// Package metrics defines the Prometheus collectors the code execution service exposes at
+// /metrics, and small helpers for recording them from executeHandler.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// ExecutionsTotal counts every completed execution, labeled by language and the error
+	// type returned (empty string for a successful run).
+	ExecutionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "aggiecode_executions_total",
+		Help: "Total number of code executions, labeled by language and error_type.",
+	}, []string{"language", "error_type"})
+
+	// ExecutionDuration observes wall-clock execution time, labeled by language.
+	ExecutionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "aggiecode_execution_duration_seconds",
+		Help:    "Execution duration in seconds, labeled by language.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"language"})
+
+	// QueueDepth is the number of requests currently waiting on admission control across all
+	// identities.
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "aggiecode_queue_depth",
+		Help: "Number of requests currently queued in admission control.",
+	})
+
+	// ConcurrentRunning is the number of executeHandler requests currently inside their
+	// codeExecutor.Execute call; batch, grade, streaming and interactive runs are not counted.
+	ConcurrentRunning = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "aggiecode_concurrent_running",
+		Help: "Number of executions currently running.",
+	})
+)
+
+// Handler returns the http.Handler to mount at /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RecordExecution records one completed execution's outcome and duration.
+func RecordExecution(language, errorType string, duration time.Duration) {
+	ExecutionsTotal.WithLabelValues(language, errorType).Inc()
+	ExecutionDuration.WithLabelValues(language).Observe(duration.Seconds())
+}
+
+// ExecutionStarted marks the start of an execution; the returned func must be called when it
+// finishes to decrement ConcurrentRunning again.
+func ExecutionStarted() func() {
+	ConcurrentRunning.Inc()
+	return ConcurrentRunning.Dec
+}
@@ -2,40 +2,31 @@ package lang
 
 import (
 	"bytes"
-	"errors"
+	"context"
 	"os"
 	"os/exec"
 )
 
-func ExecuteCppCode(containerName, code string) (string, error) {
+func ExecuteCppCode(ctx context.Context, containerName string, opts ExecOptions) (ExecutionResult, error) {
 	// 1. Write the code to a temporary .cpp file.
-	err := os.WriteFile("/tmp/main.cpp", []byte(code), 0644)
+	err := os.WriteFile("/tmp/main.cpp", []byte(opts.Code), 0644)
 	if err != nil {
-		return "", err
+		return ExecutionResult{}, err
 	}
 
 	// 2. Compile the code using g++ inside the container.
 	//    We mount the temporary directory to share files with the container.
-	compileCmd := exec.Command(
-		"docker", "exec", containerName,
+	compileCmd := exec.CommandContext(
+		ctx, "docker", "exec", containerName,
 		"g++", "-o", "/tmp/main", "/tmp/main.cpp",
 	)
 	var compileErr bytes.Buffer
 	compileCmd.Stderr = &compileErr
 	if err := compileCmd.Run(); err != nil {
-		return "", errors.New("Compilation error: " + compileErr.String())
+		return ExecutionResult{Stderr: compileErr.String(), ErrorType: ErrorTypeCompile}, nil
 	}
 
 	// 3. Execute the compiled binary inside the container.
-	execCmd := exec.Command("docker", "exec", containerName, "/tmp/main")
-	var out bytes.Buffer
-	var execErr bytes.Buffer
-	execCmd.Stdout = &out
-	execCmd.Stderr = &execErr
-
-	if err := execCmd.Run(); err != nil {
-		return "", errors.New("Execution error: " + execErr.String())
-	}
-
-	return out.String(), nil
+	argv := append([]string{"/tmp/main"}, opts.Args...)
+	return runCommand(ctx, containerName, "", argv, opts.MemoryLimitMB, opts.Stdin), nil
 }
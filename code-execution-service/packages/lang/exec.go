@@ -0,0 +1,87 @@
+package lang
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Error types an ExecutionResult.ErrorType can carry, distinguishing how a run ended beyond a
+// plain non-zero exit.
+const (
+	ErrorTypeCompile = "compile"
+	ErrorTypeRuntime = "runtime"
+	ErrorTypeTimeout = "timeout"
+	ErrorTypeOOM     = "oom"
+)
+
+// oomExitCode is the exit code `docker exec` reports when the kernel OOM-killer (SIGKILL, 128+9)
+// takes down the exec'd process. It's also the exit code a plain `docker kill` would produce, so
+// it's only trusted as an OOM signal once a context deadline has already been ruled out.
+const oomExitCode = 137
+
+// ExecOptions carries the per-request execution parameters common to every language runner.
+type ExecOptions struct {
+	Code          string
+	Stdin         string
+	MemoryLimitMB int
+	Args          []string
+}
+
+// ExecutionResult separates a run's stdout and stderr and classifies how it ended, so a caller
+// doesn't have to guess OOM vs timeout vs a plain non-zero exit from a single combined error.
+// ErrorType is empty on a clean exit.
+type ExecutionResult struct {
+	Stdout    string
+	Stderr    string
+	ErrorType string
+}
+
+// ulimitWrapper runs under `sh -c`, taking the memory ceiling in KB as $1 and the real command
+// as the rest of argv, so the wrapped command is passed along via "$@" rather than interpolated
+// into the script string - the submitted code in argv never needs shell-escaping.
+const ulimitWrapper = `ulimit -v "$1"; shift; exec "$@"`
+
+// runCommand runs argv (e.g. ["node", "-e", code, ...extraArgs]) inside containerName, piping
+// stdin in and classifying the result: a context deadline becomes ErrorTypeTimeout, exit 137
+// becomes ErrorTypeOOM, any other non-zero exit becomes ErrorTypeRuntime. workdir, if set, is
+// passed to `docker exec -w`. memoryLimitMB, if positive, applies a ulimit -v via a small shell
+// wrapper, since `docker exec` itself has no --memory flag - a container's memory ceiling can
+// only be set at `docker run` time.
+func runCommand(ctx context.Context, containerName, workdir string, argv []string, memoryLimitMB int, stdin string) ExecutionResult {
+	dockerArgs := []string{"exec", "-i"}
+	if workdir != "" {
+		dockerArgs = append(dockerArgs, "-w", workdir)
+	}
+	dockerArgs = append(dockerArgs, containerName)
+
+	if memoryLimitMB > 0 {
+		dockerArgs = append(dockerArgs, "sh", "-c", ulimitWrapper, "sh", strconv.Itoa(memoryLimitMB*1024))
+	}
+	dockerArgs = append(dockerArgs, argv...)
+
+	cmd := exec.CommandContext(ctx, "docker", dockerArgs...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	result := ExecutionResult{Stdout: stdout.String(), Stderr: stderr.String()}
+
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.ErrorType = ErrorTypeTimeout
+	case err == nil:
+		// clean exit
+	default:
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == oomExitCode {
+			result.ErrorType = ErrorTypeOOM
+		} else {
+			result.ErrorType = ErrorTypeRuntime
+		}
+	}
+	return result
+}
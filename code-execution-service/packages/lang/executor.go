@@ -0,0 +1,107 @@
+package lang
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Executor runs a single ExecOptions submission and returns its ExecutionResult. DockerExecExecutor
+// implements it the way every Execute*Code function always has - by shelling out to `docker exec`
+// per request - so it's the default for every language. HTTPExecutor is the faster alternative:
+// it proxies to a persistent sidecar process (see cmd/executor-sidecar/) that already has the
+// language runtime warmed up, instead of paying the Docker CLI/daemon round-trip on every call.
+type Executor interface {
+	Execute(ctx context.Context, opts ExecOptions) (ExecutionResult, error)
+}
+
+// dockerExecFunc is the signature every existing Execute*Code function already has.
+type dockerExecFunc func(ctx context.Context, containerName string, opts ExecOptions) (ExecutionResult, error)
+
+// DockerExecExecutor adapts one of the existing Execute*Code functions to the Executor interface,
+// binding it to a fixed containerName. This is the original per-request `docker exec` behavior,
+// kept as-is for backwards compatibility while HTTPExecutor rolls out language by language.
+type DockerExecExecutor struct {
+	ContainerName string
+	Run           dockerExecFunc
+}
+
+func (e *DockerExecExecutor) Execute(ctx context.Context, opts ExecOptions) (ExecutionResult, error) {
+	return e.Run(ctx, e.ContainerName, opts)
+}
+
+// httpExecutorRequest/httpExecutorResponse are the wire types HTTPExecutor exchanges with a
+// sidecar's /run endpoint.
+type httpExecutorRequest struct {
+	Code      string   `json:"code"`
+	Stdin     string   `json:"stdin,omitempty"`
+	Args      []string `json:"args,omitempty"`
+	TimeoutMs int64    `json:"timeout_ms,omitempty"`
+}
+
+type httpExecutorResponse struct {
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	ErrorType string `json:"error_type,omitempty"`
+}
+
+// HTTPExecutor implements Executor by POSTing to a long-lived sidecar process's /run endpoint
+// instead of shelling out to `docker exec`. The sidecar already has its runtime warmed up and
+// lives on the same internal Docker network as ExecutionService, so BaseURL is normally a
+// container DNS name, e.g. "http://python-executor:8080".
+type HTTPExecutor struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewHTTPExecutor returns an HTTPExecutor whose client pools connections to baseURL instead of
+// dialing fresh for every request, since a sidecar is expected to serve many requests over its
+// lifetime.
+func NewHTTPExecutor(baseURL string) *HTTPExecutor {
+	return &HTTPExecutor{
+		BaseURL: baseURL,
+		Client: &http.Client{
+			Transport: &http.Transport{
+				MaxIdleConns:        64,
+				MaxIdleConnsPerHost: 16,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+}
+
+func (e *HTTPExecutor) Execute(ctx context.Context, opts ExecOptions) (ExecutionResult, error) {
+	body, err := json.Marshal(httpExecutorRequest{
+		Code:  opts.Code,
+		Stdin: opts.Stdin,
+		Args:  opts.Args,
+	})
+	if err != nil {
+		return ExecutionResult{}, fmt.Errorf("marshal executor request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.BaseURL+"/run", bytes.NewReader(body))
+	if err != nil {
+		return ExecutionResult{}, fmt.Errorf("build executor request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return ExecutionResult{ErrorType: ErrorTypeTimeout}, nil
+		}
+		return ExecutionResult{}, fmt.Errorf("executor request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out httpExecutorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return ExecutionResult{}, fmt.Errorf("decode executor response: %w", err)
+	}
+
+	return ExecutionResult{Stdout: out.Stdout, Stderr: out.Stderr, ErrorType: out.ErrorType}, nil
+}
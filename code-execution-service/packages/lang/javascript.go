@@ -1,22 +1,9 @@
 package lang
 
-import (
-	"bytes"
-	"errors"
-	"os/exec"
-)
+import "context"
 
-func ExecuteJsCode(containerName, code string) (string, error) {
+func ExecuteJsCode(ctx context.Context, containerName string, opts ExecOptions) (ExecutionResult, error) {
 	// Execute the code using node inside the container.
-	execCmd := exec.Command("docker", "exec", containerName, "node", "-e", code)
-	var out bytes.Buffer
-	var errBuf bytes.Buffer
-	execCmd.Stdout = &out
-	execCmd.Stderr = &errBuf
-
-	if err := execCmd.Run(); err != nil {
-		return "", errors.New("Execution error: " + errBuf.String())
-	}
-
-	return out.String(), nil
+	argv := append([]string{"node", "-e", opts.Code}, opts.Args...)
+	return runCommand(ctx, containerName, "", argv, opts.MemoryLimitMB, opts.Stdin), nil
 }
@@ -0,0 +1,217 @@
+package lang
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OutputFrame is one chunk of output a StreamSession emits as the underlying process produces
+// it, tagged with which stream it came from.
+type OutputFrame struct {
+	Stream string // "stdout" or "stderr"
+	Data   string
+}
+
+// ExitFrame is the final frame a StreamSession emits once the underlying process has exited.
+type ExitFrame struct {
+	ErrorType string
+	Code      int
+	TimeMs    int64
+}
+
+// cancelSignal implements the setDeadline pattern common to Go's fake-net test harnesses: Done
+// returns a channel closed exactly once, either right away or when an armed timer fires, and
+// SetDeadline can replace a still-pending timer before it fires. StreamSession uses it so a
+// caller can arm/re-arm a deadline on an in-flight session, the same way net.Conn.SetDeadline
+// works.
+type cancelSignal struct {
+	mu    sync.Mutex
+	ch    chan struct{}
+	timer *time.Timer
+}
+
+func newCancelSignal() *cancelSignal {
+	return &cancelSignal{ch: make(chan struct{})}
+}
+
+func (c *cancelSignal) Done() <-chan struct{} { return c.ch }
+
+func (c *cancelSignal) closeLocked() {
+	select {
+	case <-c.ch:
+	default:
+		close(c.ch)
+	}
+}
+
+// SetDeadline closes Done immediately if t is already in the past, arms a timer to close it at t
+// otherwise (replacing any timer armed by a previous call), or - if t is the zero Time - just
+// clears a pending timer without closing Done.
+func (c *cancelSignal) SetDeadline(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if t.IsZero() {
+		return
+	}
+	if d := time.Until(t); d <= 0 {
+		c.closeLocked()
+		return
+	} else {
+		c.timer = time.AfterFunc(d, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.closeLocked()
+		})
+	}
+}
+
+// Cancel closes Done immediately.
+func (c *cancelSignal) Cancel() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+// StreamSession runs argv inside a container the way runCommand does, but exposes its
+// stdout/stderr as a channel of frames produced as the process writes them instead of buffering
+// the full output and returning it only once the process exits. This is what makes
+// ExecutionService's streaming endpoint possible - a long-running REPL-style submission can be
+// watched live - at the cost of the caller having to drain Frames and Exit itself.
+type StreamSession struct {
+	Frames chan OutputFrame
+	Exit   chan ExitFrame
+
+	stdin  io.WriteCloser
+	cancel context.CancelFunc
+	signal *cancelSignal
+}
+
+// StartSession starts argv inside containerName (mirroring runCommand's `docker exec -i` wiring)
+// and begins streaming its output. The caller must eventually call Kill, or arm a deadline via
+// SetDeadline, to guarantee the process is released.
+func StartSession(ctx context.Context, containerName, workdir string, argv []string, memoryLimitMB int) (*StreamSession, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	dockerArgs := []string{"exec", "-i"}
+	if workdir != "" {
+		dockerArgs = append(dockerArgs, "-w", workdir)
+	}
+	dockerArgs = append(dockerArgs, containerName)
+	if memoryLimitMB > 0 {
+		dockerArgs = append(dockerArgs, "sh", "-c", ulimitWrapper, "sh", strconv.Itoa(memoryLimitMB*1024))
+	}
+	dockerArgs = append(dockerArgs, argv...)
+
+	cmd := exec.CommandContext(ctx, "docker", dockerArgs...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	signal := newCancelSignal()
+	go func() {
+		// SetDeadline/Kill close signal.Done(); tear the process down when they do, the same way
+		// ctx.Done() would, instead of only reacting to the parent context.
+		<-signal.Done()
+		cancel()
+	}()
+
+	s := &StreamSession{
+		Frames: make(chan OutputFrame),
+		Exit:   make(chan ExitFrame, 1),
+		stdin:  stdin,
+		cancel: cancel,
+		signal: signal,
+	}
+
+	startedAt := time.Now()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go pumpOutput(&wg, s.Frames, "stdout", stdout)
+	go pumpOutput(&wg, s.Frames, "stderr", stderr)
+
+	go func() {
+		wg.Wait()
+		runErr := cmd.Wait()
+		frame := ExitFrame{TimeMs: time.Since(startedAt).Milliseconds()}
+		switch {
+		case ctx.Err() != nil:
+			frame.ErrorType = ErrorTypeTimeout
+		case runErr == nil:
+			// clean exit
+		default:
+			if exitErr, ok := runErr.(*exec.ExitError); ok {
+				frame.Code = exitErr.ExitCode()
+				if frame.Code == oomExitCode {
+					frame.ErrorType = ErrorTypeOOM
+				} else {
+					frame.ErrorType = ErrorTypeRuntime
+				}
+			} else {
+				frame.ErrorType = ErrorTypeRuntime
+			}
+		}
+		s.Exit <- frame
+		close(s.Exit)
+		close(s.Frames)
+	}()
+
+	return s, nil
+}
+
+func pumpOutput(wg *sync.WaitGroup, frames chan<- OutputFrame, stream string, r io.Reader) {
+	defer wg.Done()
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			frames <- OutputFrame{Stream: stream, Data: string(buf[:n])}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Write forwards data to the session's stdin, e.g. for a client's {"stdin": "..."} message.
+func (s *StreamSession) Write(data string) error {
+	_, err := io.WriteString(s.stdin, data)
+	return err
+}
+
+// SetDeadline arranges for the session to be killed at t - immediately if t is already in the
+// past - or clears a pending deadline if t is the zero Time. See cancelSignal for the underlying
+// mechanics.
+func (s *StreamSession) SetDeadline(t time.Time) {
+	s.signal.SetDeadline(t)
+}
+
+// Kill ends the session immediately, e.g. for a client's {"signal": "kill"} message.
+func (s *StreamSession) Kill() {
+	s.signal.Cancel()
+}
@@ -1,15 +1,16 @@
 package pkg
 
 import (
-	"code-execution-service/packages/lang"
+	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
-	"regexp"
+	"os"
 	"strings"
-	"sync"
+	"time"
 
-	"golang.org/x/time/rate"
+	"github.com/IMMZEK/AggieCode/code-execution-service/packages/lang"
 )
 
 var (
@@ -19,216 +20,120 @@ var (
 	ErrRateLimitExceeded    = errors.New("rate limit exceeded, please try again later")
 )
 
+// ErrorType values an ExecutionResponse can carry, identifying which stage of the request
+// rejected or failed it.
+const (
+	ErrorTypeSanitization = "sanitization"
+	ErrorTypeCompile      = lang.ErrorTypeCompile
+	ErrorTypeRuntime      = lang.ErrorTypeRuntime
+	ErrorTypeTimeout      = lang.ErrorTypeTimeout
+	ErrorTypeOOM          = lang.ErrorTypeOOM
+	ErrorTypeRateLimited  = "rate_limited"
+)
+
+// defaultTimeoutSeconds and maxTimeoutSeconds bound ExecutionRequest.Timeout: unset (<= 0) falls
+// back to the default, and anything larger is capped, so one request can't tie up a container
+// indefinitely.
+const (
+	defaultTimeoutSeconds = 10
+	maxTimeoutSeconds     = 30
+)
+
 type ExecutionRequest struct {
-	Language string `json:"language"`
-	Code     string `json:"code"`
-	Method   string `json:"method"`
+	Language      string   `json:"language"`
+	Code          string   `json:"code"`
+	Method        string   `json:"method"`
+	Stdin         string   `json:"stdin,omitempty"`
+	Timeout       int      `json:"timeout,omitempty"` // seconds; <= 0 means defaultTimeoutSeconds, capped at maxTimeoutSeconds
+	MemoryLimitMB int      `json:"memory_limit_mb,omitempty"`
+	Args          []string `json:"args,omitempty"`
 }
 
 type ExecutionResponse struct {
-	Output        string `json:"output"`
-	Error         string `json:"error,omitempty"`
-	StatusMessage string `json:"status_message,omitempty"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr,omitempty"`
+	Error           string `json:"error,omitempty"`
+	ErrorType       string `json:"error_type,omitempty"`
+	StatusMessage   string `json:"status_message,omitempty"`
+	ExecutionTimeMs int64  `json:"execution_time_ms"`
+}
+
+// clampTimeout resolves an ExecutionRequest.Timeout (seconds, 0 meaning unset) to the deadline
+// HandleExecute should actually run with.
+func clampTimeout(seconds int) time.Duration {
+	switch {
+	case seconds <= 0:
+		seconds = defaultTimeoutSeconds
+	case seconds > maxTimeoutSeconds:
+		seconds = maxTimeoutSeconds
+	}
+	return time.Duration(seconds) * time.Second
 }
 
 type ExecutionService struct {
 	containers  map[string]string
+	executors   map[string]lang.Executor
 	RateLimiter *RateLimiter
 	Sanitizer   *Sanitizer
 }
 
-type RateLimiter struct {
-	visitors map[string]*rate.Limiter
-	mu       sync.RWMutex
-	limit    rate.Limit
-	burst    int
-}
-
-type Sanitizer struct {
-	maxCodeLength int
-}
-
-type SanitizationError struct {
-	Message string `json:"message"`
-	Details string `json:"details"`
-}
-
-func (e *SanitizationError) Error() string {
-	return e.Message
-}
-
-func (s *Sanitizer) SanitizeCode(code, language string) error {
-	if len(code) > s.maxCodeLength {
-		return &SanitizationError{
-			Message: "Code length exceeds maximum limit",
-			Details: "Max length allowed is " + string(rune(s.maxCodeLength)),
-		}
-	}
-
-	systemPatterns := []string{
-		`(?i)(subprocess|exec\.|shell|eval|child_process)`,
-		`(?i)(io/ioutil|os\.Open|os\.Create|os\.Remove)`,
-		`(?i)(net\.Listen|net\.Dial|http\.|urllib|axios)`,
-	}
-	if matched, err := matchPatterns(systemPatterns, code); err != nil || matched {
-		return &SanitizationError{
-			Message: "Prohibited system-level access detected",
-			Details: "Code contains restricted system operations",
-		}
-	}
-
-	var restrictedPatterns []string
-	switch language {
-	case "python":
-		if strings.Contains(code, "import") || strings.Contains(code, "from") {
-			restrictedPatterns = []string{
-				`^import\s+(?!math|random|datetime|json|re|string|collections|itertools|functools|typing).*$`,
-				`^from\s+(?!math|random|datetime|json|re|string|collections|itertools|functools|typing)\s+import.*$`,
-			}
-		}
-		restrictedPatterns = append(restrictedPatterns, []string{
-			`__import__`, `globals|locals|vars`, `getattr|setattr|delattr`,
-			`pip|setuptools|pkg_resources`,
-		}...)
-	case "go":
-		safePackages := []string{
-			"fmt", "strings", "strconv", "math", "time", "encoding/json", "errors",
-			"sort", "regexp",
-		}
-
-		if strings.Contains(code, "import") {
-			lines := strings.Split(code, "\n")
-			for _, line := range lines {
-				line = strings.TrimSpace(line)
-				if strings.HasPrefix(line, "import") {
-					importMatch := regexp.MustCompile(`^import\s+"([^"]+)"`).FindStringSubmatch(line)
-					if importMatch != nil {
-						pkg := importMatch[1]
-						isSafe := false
-						for _, safePkg := range safePackages {
-							if pkg == safePkg {
-								isSafe = true
-								break
-							}
-						}
-						if !isSafe {
-							return &SanitizationError{
-								Message: "Prohibited go code pattern detected",
-								Details: "Unauthorized import: " + pkg,
-							}
-						}
-					}
-				}
-			}
-		}
-
-		restrictedPatterns = []string{
-			`unsafe\.`, `reflect\.`, `plugin\.`, `go/ast`,
-			`syscall\.`, `debug\.`, `runtime\.`, `os\.Exit`, `panic\(`,
-		}
-	case "js":
-		if strings.Contains(code, "require") || strings.Contains(code, "import") {
-			restrictedPatterns = []string{
-				`require\(.*\)`, `import\s+.*\s+from`, `import\s*{.*}`,
-			}
-		}
-		restrictedPatterns = append(restrictedPatterns, []string{
-			`process`, `global`, `Buffer`, `__proto__`, `prototype`,
-			`fs`, `child_process`, `eval`, `Function`, `process\.env`}...)
-	default:
-		return errors.New("unsupported language: " + language)
+func NewExecutionService() *ExecutionService {
+	containers := map[string]string{
+		"cpp":    "cpp-executor",
+		"java":   "java-executor",
+		"js":     "js-executor",
+		"python": "python-executor",
+		"go":     "go-executor",
 	}
 
-	if len(restrictedPatterns) > 0 {
-		if matched, err := matchPatterns(restrictedPatterns, code); err != nil || matched {
-			return &SanitizationError{
-				Message: "Prohibited " + language + " code pattern detected",
-				Details: "Unauthorized module or operation",
-			}
-		}
+	// DockerExecExecutor wraps each language's original Execute*Code function, so the default
+	// behavior is unchanged: a new Executor implementation (e.g. lang.NewHTTPExecutor, once a
+	// language's sidecar image exists) can be swapped in per language without touching
+	// HandleExecute.
+	executors := map[string]lang.Executor{
+		"cpp":    &lang.DockerExecExecutor{ContainerName: containers["cpp"], Run: lang.ExecuteCppCode},
+		"java":   &lang.DockerExecExecutor{ContainerName: containers["java"], Run: lang.ExecuteJavaCode},
+		"js":     &lang.DockerExecExecutor{ContainerName: containers["js"], Run: lang.ExecuteJsCode},
+		"python": &lang.DockerExecExecutor{ContainerName: containers["python"], Run: lang.ExecutePythonCode},
+		"go":     &lang.DockerExecExecutor{ContainerName: containers["go"], Run: lang.ExecuteGoCode},
 	}
 
-	return nil
-}
+	rateLimiter := NewRateLimiter(100, 10) // 100 requests per minute, burst of 10
+	rateLimiter.SetTrustedProxies(trustedProxiesFromEnv())
 
-func matchPatterns(patterns []string, text string) (bool, error) {
-	for _, pattern := range patterns {
-		match, err := regexp.MatchString(pattern, text)
-		if err != nil {
-			return false, err
-		}
-		if match {
-			return true, nil
-		}
-	}
-	return false, nil
-}
-
-func NewExecutionService() *ExecutionService {
 	return &ExecutionService{
-		containers: map[string]string{
-			"cpp":    "cpp-executor",
-			"java":   "java-executor",
-			"js":     "js-executor",
-			"python": "python-executor",
-			"go":     "go-executor",
-		},
-		RateLimiter: NewRateLimiter(100, 10), // 100 requests per minute, burst of 10
+		containers:  containers,
+		executors:   executors,
+		RateLimiter: rateLimiter,
 		Sanitizer:   NewSanitizer(1000),
 	}
 }
 
-func NewRateLimiter(requestsPerMinute, burst int) *RateLimiter {
-	return &RateLimiter{
-		visitors: make(map[string]*rate.Limiter),
-		limit:    rate.Limit(requestsPerMinute) / 60, // Convert to per-second rate
-		burst:    burst,
+// trustedProxiesFromEnv parses TRUSTED_PROXIES, a comma-separated list of CIDRs (e.g.
+// "10.0.0.0/8,192.168.1.0/24"), into the networks RateLimiter.clientIP trusts to supply a client
+// IP via X-Forwarded-For/X-Real-IP instead of RemoteAddr - this service is meant to sit behind a
+// reverse proxy, so without this, clientIP always falls back to "peer:" + RemoteAddr, which in
+// production is the proxy's own address for every request. Entries that fail to parse are
+// skipped rather than rejected outright, so one typo doesn't take rate limiting down entirely.
+func trustedProxiesFromEnv() []*net.IPNet {
+	raw := os.Getenv("TRUSTED_PROXIES")
+	if raw == "" {
+		return nil
 	}
-}
 
-func NewSanitizer(maxSize int) *Sanitizer {
-	return &Sanitizer{
-		maxCodeLength: maxSize,
-	}
-}
-
-func (rl *RateLimiter) getVisitor(ip string) *rate.Limiter {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	limiter, exists := rl.visitors[ip]
-	if !exists {
-		limiter = rate.NewLimiter(rl.limit, rl.burst)
-		rl.visitors[ip] = limiter
-	}
-
-	return limiter
-}
-
-func (rl *RateLimiter) cleanupVisitors() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	for ip, limiter := range rl.visitors {
-		// Use Tokens() to check if the limiter has been inactive
-		if limiter.Tokens() == float64(rl.burst) {
-			delete(rl.visitors, ip)
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
 		}
-	}
-}
-
-func (rl *RateLimiter) Limit(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := r.RemoteAddr
-		limiter := rl.getVisitor(ip)
-
-		if !limiter.Allow() {
-			http.Error(w, ErrRateLimitExceeded.Error(), http.StatusTooManyRequests)
-			return
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			continue
 		}
-
-		next.ServeHTTP(w, r)
-	})
+		proxies = append(proxies, network)
+	}
+	return proxies
 }
 
 func (s *ExecutionService) validateRequest(req *ExecutionRequest) error {
@@ -261,10 +166,14 @@ func (s *ExecutionService) HandleExecute(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Sanitize the code
-	if err := s.Sanitizer.SanitizeCode(req.Code, req.Language); err != nil {
+	// Sanitize the code. containerName is the same container the executor will run it in, so the
+	// Python analyzer can reuse it to parse the submission's AST in place rather than needing a
+	// container of its own.
+	containerName := s.containers[req.Language]
+	if err := s.Sanitizer.SanitizeCode(req.Code, req.Language, containerName); err != nil {
 		response := ExecutionResponse{
 			Error:         err.Error(),
+			ErrorType:     ErrorTypeSanitization,
 			StatusMessage: "Code Sanitization Error",
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -273,15 +182,32 @@ func (s *ExecutionService) HandleExecute(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(r.Context(), clampTimeout(req.Timeout))
+	defer cancel()
+
 	// Execute the code
-	output, err := ExecuteCode(req.Language, req.Code, req.Method)
+	startTime := time.Now()
+	result, err := s.executeCode(ctx, req.Language, lang.ExecOptions{
+		Code:          req.Code,
+		Stdin:         req.Stdin,
+		MemoryLimitMB: req.MemoryLimitMB,
+		Args:          req.Args,
+	})
 	response := ExecutionResponse{
-		Output:        output,
-		StatusMessage: "Accepted",
+		Stdout:          result.Stdout,
+		Stderr:          result.Stderr,
+		ErrorType:       result.ErrorType,
+		StatusMessage:   "Accepted",
+		ExecutionTimeMs: time.Since(startTime).Milliseconds(),
 	}
 
-	if err != nil {
+	switch {
+	case err != nil:
 		response.Error = err.Error()
+		response.ErrorType = ErrorTypeRuntime
+		response.StatusMessage = "Runtime Error"
+		w.WriteHeader(http.StatusInternalServerError)
+	case result.ErrorType != "":
 		response.StatusMessage = "Runtime Error"
 		w.WriteHeader(http.StatusInternalServerError)
 	}
@@ -294,31 +220,13 @@ func (s *ExecutionService) HandleExecute(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-func ExecuteCode(language, code, method string) (string, error) {
-	containerName, ok := map[string]string{
-		"cpp":    "cpp-executor",
-		"java":   "java-executor",
-		"js":     "js-executor",
-		"python": "python-executor",
-		"go":     "go-executor",
-	}[language]
-
+// executeCode dispatches to the Executor registered for language - normally a
+// lang.DockerExecExecutor wrapping the language's Execute*Code function, but swappable per
+// language for a lang.HTTPExecutor once that language's sidecar image exists.
+func (s *ExecutionService) executeCode(ctx context.Context, language string, opts lang.ExecOptions) (lang.ExecutionResult, error) {
+	executor, ok := s.executors[language]
 	if !ok {
-		return "", ErrLanguageNotSupported
-	}
-
-	switch language {
-	case "cpp":
-		return lang.ExecuteCppCode(containerName, code)
-	case "java":
-		return lang.ExecuteJavaCode(containerName, code)
-	case "js":
-		return lang.ExecuteJsCode(containerName, code)
-	case "python":
-		return lang.ExecutePythonCode(containerName, code)
-	case "go":
-		return lang.ExecuteGoCode(containerName, code)
-	default:
-		return "", ErrLanguageNotSupported
+		return lang.ExecutionResult{}, ErrLanguageNotSupported
 	}
+	return executor.Execute(ctx, opts)
 }
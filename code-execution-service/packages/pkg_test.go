@@ -3,9 +3,11 @@ package pkg
 import (
 	"bytes"
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestValidateRequest(t *testing.T) {
@@ -68,22 +70,18 @@ func TestSanitizeCode(t *testing.T) {
 	sanitizer := NewSanitizer(1000)
 
 	tests := []struct {
-		name     string
-		code     string
-		language string
-		wantErr  bool
+		name          string
+		code          string
+		language      string
+		containerName string
+		wantErr       bool
 	}{
 		{
-			name:     "valid python code",
-			code:     "print('hello')",
-			language: "python",
-			wantErr:  false,
-		},
-		{
-			name:     "python code with system access",
-			code:     "import os\nos.system('rm -rf /')",
-			language: "python",
-			wantErr:  true,
+			name:          "python code with banned import",
+			code:          "import os\nos.system('rm -rf /')",
+			language:      "python",
+			containerName: "python-executor",
+			wantErr:       true,
 		},
 		{
 			name:     "valid go code",
@@ -97,11 +95,35 @@ func TestSanitizeCode(t *testing.T) {
 			language: "go",
 			wantErr:  true,
 		},
+		{
+			name:     "go code with banned selector via dot import",
+			code:     "package main\n\nimport . \"unsafe\"\n\nfunc main() {\n\tvar _ = unsafe.Pointer(nil)\n}",
+			language: "go",
+			wantErr:  true,
+		},
+		{
+			name:     "valid js code",
+			code:     "function greet(name) {\n\treturn 'hello ' + name;\n}\nconsole.log(greet('world'));",
+			language: "js",
+			wantErr:  false,
+		},
+		{
+			name:     "js code referencing banned global",
+			code:     "const p = process;\np.exit(1);",
+			language: "js",
+			wantErr:  true,
+		},
+		{
+			name:     "js code with banned identifier in a variable initializer",
+			code:     "const fn = eval;\nfn('1+1');",
+			language: "js",
+			wantErr:  true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := sanitizer.SanitizeCode(tt.code, tt.language)
+			err := sanitizer.SanitizeCode(tt.code, tt.language, tt.containerName)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SanitizeCode() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -117,7 +139,7 @@ func TestHandleExecute(t *testing.T) {
 		request        ExecutionRequest
 		wantStatus     int
 		wantErrInBody  bool
-		wantOutputNull bool
+		wantStdoutNull bool
 	}{
 		{
 			name: "valid python request",
@@ -128,7 +150,7 @@ func TestHandleExecute(t *testing.T) {
 			},
 			wantStatus:     http.StatusOK,
 			wantErrInBody:  false,
-			wantOutputNull: false,
+			wantStdoutNull: false,
 		},
 		{
 			name: "invalid language",
@@ -139,7 +161,7 @@ func TestHandleExecute(t *testing.T) {
 			},
 			wantStatus:     http.StatusOK,
 			wantErrInBody:  true,
-			wantOutputNull: true,
+			wantStdoutNull: true,
 		},
 		{
 			name: "dangerous code",
@@ -150,7 +172,7 @@ func TestHandleExecute(t *testing.T) {
 			},
 			wantStatus:     http.StatusOK,
 			wantErrInBody:  true,
-			wantOutputNull: true,
+			wantStdoutNull: true,
 		},
 	}
 
@@ -177,22 +199,54 @@ func TestHandleExecute(t *testing.T) {
 				t.Errorf("HandleExecute() unexpected error in response body: %v", response.Error)
 			}
 
-			if tt.wantOutputNull && response.Output != "" {
-				t.Error("HandleExecute() expected null output, got output")
+			if tt.wantStdoutNull && response.Stdout != "" {
+				t.Error("HandleExecute() expected null stdout, got stdout")
 			}
 		})
 	}
 }
 
+func TestTimeoutMiddleware(t *testing.T) {
+	service := NewExecutionService()
+	handler := TimeoutMiddleware(http.HandlerFunc(service.HandleExecute), time.Second, 200*time.Millisecond)
+
+	ts := httptest.NewUnstartedServer(handler)
+	ts.Config.WriteTimeout = time.Second
+	ts.Start()
+	defer ts.Close()
+
+	reqBody, _ := json.Marshal(ExecutionRequest{
+		Language: "python",
+		Code:     "import time\ntime.sleep(5)",
+		Method:   "run",
+	})
+
+	resp, err := http.Post(ts.URL, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response ExecutionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("response body was not valid JSON: %v", err)
+	}
+
+	if response.ErrorType != ErrorTypeTimeout {
+		t.Errorf("ErrorType = %q, want %q", response.ErrorType, ErrorTypeTimeout)
+	}
+}
+
 func TestRateLimiter(t *testing.T) {
 	limiter := NewRateLimiter(2, 1) // 2 requests per minute, burst of 1
+	defer limiter.Close()
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 
 	limitedHandler := limiter.Limit(handler)
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
-	req.RemoteAddr = "127.0.0.1"
+	req.RemoteAddr = "127.0.0.1:54321"
 
 	// First request should succeed
 	w1 := httptest.NewRecorder()
@@ -201,10 +255,81 @@ func TestRateLimiter(t *testing.T) {
 		t.Errorf("First request: got status %v, want %v", w1.Code, http.StatusOK)
 	}
 
-	// Second request should be rate limited
+	// A second request from the same IP but a different ephemeral port should still be rate
+	// limited - RemoteAddr's port must be stripped before keying the visitor map.
+	req2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	req2.RemoteAddr = "127.0.0.1:9999"
 	w2 := httptest.NewRecorder()
-	limitedHandler.ServeHTTP(w2, req)
+	limitedHandler.ServeHTTP(w2, req2)
 	if w2.Code != http.StatusTooManyRequests {
 		t.Errorf("Second request: got status %v, want %v", w2.Code, http.StatusTooManyRequests)
 	}
+
+	if got := w2.Header().Get("Retry-After"); got == "" {
+		t.Error("rate-limited response missing Retry-After header")
+	}
+	if got := w2.Header().Get("X-RateLimit-Limit"); got != "2" {
+		t.Errorf("X-RateLimit-Limit = %q, want %q", got, "2")
+	}
+	if got := w2.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q, want %q", got, "0")
+	}
+
+	var response ExecutionResponse
+	if err := json.NewDecoder(w2.Body).Decode(&response); err != nil {
+		t.Fatalf("rate-limited body was not valid JSON: %v", err)
+	}
+	if response.ErrorType != ErrorTypeRateLimited {
+		t.Errorf("ErrorType = %q, want %q", response.ErrorType, ErrorTypeRateLimited)
+	}
+}
+
+func TestRateLimiterTrustedProxy(t *testing.T) {
+	limiter := NewRateLimiter(2, 1)
+	defer limiter.Close()
+	_, trustedNet, _ := net.ParseCIDR("10.0.0.0/8")
+	limiter.SetTrustedProxies([]*net.IPNet{trustedNet})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	limitedHandler := limiter.Limit(handler)
+
+	// Two different clients behind the same trusted reverse proxy, distinguished only by
+	// X-Forwarded-For, should get independent buckets.
+	reqA := httptest.NewRequest(http.MethodGet, "/", nil)
+	reqA.RemoteAddr = "10.0.0.1:12345"
+	reqA.Header.Set("X-Forwarded-For", "203.0.113.1")
+
+	reqB := httptest.NewRequest(http.MethodGet, "/", nil)
+	reqB.RemoteAddr = "10.0.0.1:54321"
+	reqB.Header.Set("X-Forwarded-For", "203.0.113.2")
+
+	for _, req := range []*http.Request{reqA, reqB} {
+		w := httptest.NewRecorder()
+		limitedHandler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("first request from %s: got status %v, want %v", req.Header.Get("X-Forwarded-For"), w.Code, http.StatusOK)
+		}
+	}
+
+	// An untrusted peer's X-Forwarded-For must be ignored, so it can't dodge the limiter by
+	// spoofing a fresh IP on every request.
+	reqC := httptest.NewRequest(http.MethodGet, "/", nil)
+	reqC.RemoteAddr = "203.0.113.1:1"
+	reqC.Header.Set("X-Forwarded-For", "198.51.100.1")
+	w := httptest.NewRecorder()
+	limitedHandler.ServeHTTP(w, reqC)
+	if w.Code != http.StatusOK {
+		t.Errorf("untrusted peer first request: got status %v, want %v", w.Code, http.StatusOK)
+	}
+
+	reqD := httptest.NewRequest(http.MethodGet, "/", nil)
+	reqD.RemoteAddr = "203.0.113.1:2"
+	reqD.Header.Set("X-Forwarded-For", "198.51.100.2") // different forwarded IP, same untrusted peer
+	w2 := httptest.NewRecorder()
+	limitedHandler.ServeHTTP(w2, reqD)
+	if w2.Code != http.StatusTooManyRequests {
+		t.Errorf("untrusted peer second request: got status %v, want %v (X-Forwarded-For should be ignored)", w2.Code, http.StatusTooManyRequests)
+	}
 }
@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// visitorIdleTimeout is how long a visitor can go unseen before cleanupVisitors evicts it.
+const visitorIdleTimeout = 3 * time.Minute
+
+// janitorInterval is how often NewRateLimiter's background goroutine sweeps for idle visitors.
+const janitorInterval = time.Minute
+
+// visitor pairs a per-IP rate.Limiter with the last time it was used. lastSeen - not
+// limiter.Tokens()==burst - is what cleanupVisitors evicts on: a visitor sitting at a full bucket
+// is indistinguishable from one that's never been seen, so the old heuristic never actually aged
+// anything out.
+type visitor struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type RateLimiter struct {
+	visitors          map[string]*visitor
+	mu                sync.RWMutex
+	limit             rate.Limit
+	burst             int
+	requestsPerMinute int
+
+	// trustedProxies lists the direct-peer networks allowed to supply a client IP via
+	// X-Forwarded-For/X-Real-IP. Anyone else's forwarded headers are ignored - otherwise a client
+	// could simply set its own and dodge the limiter entirely.
+	trustedProxies []*net.IPNet
+
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+// NewRateLimiter starts a background janitor goroutine that evicts idle visitors every
+// janitorInterval; call Close to stop it.
+func NewRateLimiter(requestsPerMinute, burst int) *RateLimiter {
+	rl := &RateLimiter{
+		visitors:          make(map[string]*visitor),
+		limit:             rate.Limit(requestsPerMinute) / 60, // Convert to per-second rate
+		burst:             burst,
+		requestsPerMinute: requestsPerMinute,
+		ticker:            time.NewTicker(janitorInterval),
+		stop:              make(chan struct{}),
+	}
+	go rl.janitor()
+	return rl
+}
+
+func (rl *RateLimiter) janitor() {
+	for {
+		select {
+		case <-rl.ticker.C:
+			rl.cleanupVisitors()
+		case <-rl.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background janitor goroutine. ExecutionServices that live for the process's
+// whole lifetime don't need to call it, but anything that creates short-lived RateLimiters (e.g.
+// tests) should, to avoid leaking the goroutine.
+func (rl *RateLimiter) Close() {
+	rl.ticker.Stop()
+	close(rl.stop)
+}
+
+// SetTrustedProxies configures which direct-peer networks may supply a client IP via
+// X-Forwarded-For/X-Real-IP instead of RemoteAddr.
+func (rl *RateLimiter) SetTrustedProxies(proxies []*net.IPNet) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.trustedProxies = proxies
+}
+
+func (rl *RateLimiter) isTrustedProxy(ip net.IP) bool {
+	for _, proxy := range rl.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP resolves the key to rate-limit on: RemoteAddr with the ephemeral port stripped,
+// unless RemoteAddr is a trusted proxy and the request carries X-Forwarded-For or X-Real-IP, in
+// which case the original client IP from that header is used instead. The two cases are
+// namespaced with "fwd:"/"peer:" prefixes the way admission.go's identityFor prefixes with
+// "key:"/"ip:" - otherwise a forwarded IP and an unrelated untrusted peer whose raw RemoteAddr
+// happens to match the same string would collide in the same bucket.
+func (rl *RateLimiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer := net.ParseIP(host)
+	rl.mu.RLock()
+	trusted := peer != nil && rl.isTrustedProxy(peer)
+	rl.mu.RUnlock()
+	if !trusted {
+		return "peer:" + host
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		// X-Forwarded-For is a comma-separated list appended to by each hop; the first entry is
+		// the original client.
+		if client := strings.TrimSpace(strings.Split(xff, ",")[0]); client != "" {
+			return "fwd:" + client
+		}
+	}
+	if xri := r.Header.Get("X-Real-IP"); xri != "" {
+		return "fwd:" + xri
+	}
+	return "peer:" + host
+}
+
+func (rl *RateLimiter) getVisitor(ip string) *visitor {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	v, exists := rl.visitors[ip]
+	if !exists {
+		v = &visitor{limiter: rate.NewLimiter(rl.limit, rl.burst)}
+		rl.visitors[ip] = v
+	}
+	v.lastSeen = time.Now()
+	return v
+}
+
+func (rl *RateLimiter) cleanupVisitors() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	cutoff := time.Now().Add(-visitorIdleTimeout)
+	for ip, v := range rl.visitors {
+		if v.lastSeen.Before(cutoff) {
+			delete(rl.visitors, ip)
+		}
+	}
+}
+
+func (rl *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := rl.getVisitor(rl.clientIP(r))
+
+		if !v.limiter.Allow() {
+			rl.writeRateLimited(w, v)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.requestsPerMinute))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(v.limiter.Tokens())))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeRateLimited writes the headers a well-behaved client backs off on (Retry-After in
+// seconds, plus the X-RateLimit-* pair) and the same ExecutionResponse JSON shape HandleExecute
+// itself uses, so callers don't need a separate error-parsing path just for rate limiting.
+func (rl *RateLimiter) writeRateLimited(w http.ResponseWriter, v *visitor) {
+	retryAfter := time.Second
+	if rl.limit > 0 {
+		retryAfter = time.Duration(float64(time.Second) / float64(rl.limit))
+	}
+
+	h := w.Header()
+	h.Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	h.Set("X-RateLimit-Limit", strconv.Itoa(rl.requestsPerMinute))
+	h.Set("X-RateLimit-Remaining", "0")
+	h.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(ExecutionResponse{
+		Error:         ErrRateLimitExceeded.Error(),
+		ErrorType:     ErrorTypeRateLimited,
+		StatusMessage: "Rate Limit Exceeded",
+	})
+}
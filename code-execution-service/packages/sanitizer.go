@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sanitizer rejects a code submission before it ever reaches the executor. It enforces a single
+// language-independent size limit itself, then delegates the actual code inspection to a
+// per-language LanguageSanitizer, each of which parses the submission into a real AST instead of
+// pattern-matching its source text.
+type Sanitizer struct {
+	maxCodeLength int
+	analyzers     map[string]LanguageSanitizer
+}
+
+// LanguageSanitizer inspects one language's AST for disallowed imports and calls. containerName
+// is the container the executor will eventually run the same code in; analyzers that have no
+// in-process Go parser for their language (Python) use it to run a preprocessor there instead of
+// spinning up a container of their own.
+type LanguageSanitizer interface {
+	Sanitize(code, containerName string) *SanitizationError
+}
+
+// SanitizationError reports the first disallowed construct a LanguageSanitizer found. Line and
+// Column are 1-based source positions and are zero when the violation isn't tied to a specific
+// node (e.g. the overall size-limit check). The JavaScript analyzer does not record source
+// positions, so its errors always carry a zero Line and Column. NodeKind is the AST node type that tripped the
+// check, e.g. "ImportSpec" or "CallExpression", to help a caller distinguish an import violation
+// from a banned call without parsing Details.
+type SanitizationError struct {
+	Message  string `json:"message"`
+	Details  string `json:"details"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	NodeKind string `json:"node_kind,omitempty"`
+}
+
+func (e *SanitizationError) Error() string {
+	return e.Message
+}
+
+// NewSanitizer constructs a Sanitizer with the built-in Go, Python, and JavaScript analyzers.
+func NewSanitizer(maxSize int) *Sanitizer {
+	return &Sanitizer{
+		maxCodeLength: maxSize,
+		analyzers: map[string]LanguageSanitizer{
+			"go":     &goSanitizer{},
+			"python": &pythonSanitizer{},
+			"js":     &jsSanitizer{},
+		},
+	}
+}
+
+// SanitizeCode rejects code that's too long or whose language has no registered analyzer, then
+// hands it to that language's LanguageSanitizer.
+func (s *Sanitizer) SanitizeCode(code, language, containerName string) error {
+	if len(code) > s.maxCodeLength {
+		return &SanitizationError{
+			Message: "Code length exceeds maximum limit",
+			Details: fmt.Sprintf("max length allowed is %d characters", s.maxCodeLength),
+		}
+	}
+
+	analyzer, ok := s.analyzers[language]
+	if !ok {
+		return errors.New("unsupported language: " + language)
+	}
+
+	if sanErr := analyzer.Sanitize(code, containerName); sanErr != nil {
+		return sanErr
+	}
+	return nil
+}
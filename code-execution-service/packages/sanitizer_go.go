@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// goSafePackages is the import allowlist for submitted Go code. Anything not listed here -
+// "os", "net", "syscall", "unsafe", "reflect", etc. - is rejected at the import itself, before
+// goBannedSelectors even gets a chance to look at how it's used.
+var goSafePackages = map[string]bool{
+	"fmt": true, "strings": true, "strconv": true, "math": true, "time": true,
+	"encoding/json": true, "errors": true, "sort": true, "regexp": true,
+}
+
+// goBannedSelectors blocks package-qualified identifiers that have no legitimate use in a
+// sandboxed submission, as a defense-in-depth check against goSafePackages alone: a dot-import
+// or an alias can make a SelectorExpr's package name lie, but not its own identifier.
+var goBannedSelectors = map[string]bool{
+	"unsafe": true, "reflect": true, "plugin": true, "syscall": true, "debug": true, "runtime": true,
+}
+
+// goSanitizer parses the submission with go/parser and walks the resulting go/ast tree looking
+// for an unauthorized ImportSpec, a SelectorExpr into a banned package (or os.Exit specifically),
+// or a bare call to panic.
+type goSanitizer struct{}
+
+func (g *goSanitizer) Sanitize(code, _ string) *SanitizationError {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "submission.go", code, parser.AllErrors)
+	if err != nil {
+		return &SanitizationError{
+			Message:  "Go code failed to parse",
+			Details:  err.Error(),
+			NodeKind: "SyntaxError",
+		}
+	}
+
+	var found *SanitizationError
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+
+		switch node := n.(type) {
+		case *ast.ImportSpec:
+			path := strings.Trim(node.Path.Value, `"`)
+			if !goSafePackages[path] {
+				pos := fset.Position(node.Pos())
+				found = &SanitizationError{
+					Message:  "Prohibited go code pattern detected",
+					Details:  "unauthorized import: " + path,
+					Line:     pos.Line,
+					Column:   pos.Column,
+					NodeKind: "ImportSpec",
+				}
+				return false
+			}
+		case *ast.SelectorExpr:
+			ident, ok := node.X.(*ast.Ident)
+			if !ok {
+				break
+			}
+			if goBannedSelectors[ident.Name] || (ident.Name == "os" && node.Sel.Name == "Exit") {
+				pos := fset.Position(node.Pos())
+				found = &SanitizationError{
+					Message:  "Prohibited go code pattern detected",
+					Details:  "unauthorized use of " + ident.Name + "." + node.Sel.Name,
+					Line:     pos.Line,
+					Column:   pos.Column,
+					NodeKind: "SelectorExpr",
+				}
+				return false
+			}
+		case *ast.CallExpr:
+			if ident, ok := node.Fun.(*ast.Ident); ok && ident.Name == "panic" {
+				pos := fset.Position(node.Pos())
+				found = &SanitizationError{
+					Message:  "Prohibited go code pattern detected",
+					Details:  "unauthorized call to panic",
+					Line:     pos.Line,
+					Column:   pos.Column,
+					NodeKind: "CallExpr",
+				}
+				return false
+			}
+		}
+		return true
+	})
+
+	return found
+}
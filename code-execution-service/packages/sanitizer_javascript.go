@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+)
+
+// jsBannedIdentifiers are global identifiers with no legitimate use inside a sandboxed
+// submission: they're either Node-only (process, require, global, Buffer) or a way to run
+// arbitrary strings as code (eval, Function), whether they're called outright or just referenced
+// (e.g. `const p = process; p.exit()`).
+var jsBannedIdentifiers = map[string]bool{
+	"require": true, "eval": true, "Function": true, "process": true, "global": true, "Buffer": true,
+}
+
+// jsSanitizer parses the submission with goja/parser and walks the resulting AST for a reference
+// to a banned global. It covers the expression and statement forms a submission is realistically
+// built from (calls, member access, assignments, control flow, function bodies); anything this
+// walk doesn't recurse into is a gap to close as a follow-up, not a silent bypass it was designed
+// to allow.
+type jsSanitizer struct{}
+
+func (j *jsSanitizer) Sanitize(code, _ string) *SanitizationError {
+	program, err := parser.ParseFile(nil, "submission.js", code, 0)
+	if err != nil {
+		return &SanitizationError{
+			Message:  "JavaScript code failed to parse",
+			Details:  err.Error(),
+			NodeKind: "SyntaxError",
+		}
+	}
+
+	w := &jsWalker{}
+	for _, stmt := range program.Body {
+		if w.found != nil {
+			break
+		}
+		w.walkStatement(stmt)
+	}
+	return w.found
+}
+
+type jsWalker struct {
+	found *SanitizationError
+}
+
+func (w *jsWalker) reject(name, nodeKind string) {
+	if w.found == nil {
+		w.found = &SanitizationError{
+			Message:  "Prohibited javascript code pattern detected",
+			Details:  "unauthorized reference: " + name,
+			NodeKind: nodeKind,
+		}
+	}
+}
+
+func (w *jsWalker) walkStatement(stmt ast.Statement) {
+	if w.found != nil || stmt == nil {
+		return
+	}
+
+	switch s := stmt.(type) {
+	case *ast.ExpressionStatement:
+		w.walkExpression(s.Expression)
+	case *ast.BlockStatement:
+		for _, inner := range s.List {
+			w.walkStatement(inner)
+		}
+	case *ast.IfStatement:
+		w.walkExpression(s.Test)
+		w.walkStatement(s.Consequent)
+		w.walkStatement(s.Alternate)
+	case *ast.ReturnStatement:
+		w.walkExpression(s.Argument)
+	case *ast.VariableStatement:
+		for _, binding := range s.List {
+			w.walkExpression(binding.Initializer)
+		}
+	case *ast.LexicalDeclaration:
+		// const/let declarations - parser.ParseFile reports these as LexicalDeclaration, not
+		// VariableStatement (that's var-only), so `const p = process;` needs its own case here.
+		for _, binding := range s.List {
+			w.walkExpression(binding.Initializer)
+		}
+	case *ast.ForStatement:
+		w.walkForLoopInitializer(s.Initializer)
+		w.walkExpression(s.Test)
+		w.walkExpression(s.Update)
+		w.walkStatement(s.Body)
+	case *ast.ForInStatement:
+		w.walkExpression(s.Source)
+		w.walkStatement(s.Body)
+	case *ast.ForOfStatement:
+		w.walkExpression(s.Source)
+		w.walkStatement(s.Body)
+	case *ast.WhileStatement:
+		w.walkExpression(s.Test)
+		w.walkStatement(s.Body)
+	case *ast.DoWhileStatement:
+		w.walkExpression(s.Test)
+		w.walkStatement(s.Body)
+	case *ast.SwitchStatement:
+		w.walkExpression(s.Discriminant)
+		for _, c := range s.Body {
+			w.walkExpression(c.Test)
+			for _, inner := range c.Consequent {
+				w.walkStatement(inner)
+			}
+		}
+	case *ast.TryStatement:
+		w.walkStatement(s.Body)
+		if s.Catch != nil {
+			w.walkStatement(s.Catch.Body)
+		}
+		if s.Finally != nil {
+			w.walkStatement(s.Finally)
+		}
+	case *ast.ThrowStatement:
+		w.walkExpression(s.Argument)
+	case *ast.LabelledStatement:
+		w.walkStatement(s.Statement)
+	case *ast.FunctionDeclaration:
+		if s.Function != nil && s.Function.Body != nil {
+			w.walkStatement(s.Function.Body)
+		}
+	}
+}
+
+// walkForLoopInitializer walks the init clause of a classic for(;;) loop, which the parser
+// represents as one of three ForLoopInitializer variants depending on whether it's a bare
+// expression, a var declaration list, or a let/const declaration.
+func (w *jsWalker) walkForLoopInitializer(init ast.ForLoopInitializer) {
+	switch i := init.(type) {
+	case *ast.ForLoopInitializerExpression:
+		w.walkExpression(i.Expression)
+	case *ast.ForLoopInitializerVarDeclList:
+		for _, binding := range i.List {
+			w.walkExpression(binding.Initializer)
+		}
+	case *ast.ForLoopInitializerLexicalDecl:
+		for _, binding := range i.LexicalDeclaration.List {
+			w.walkExpression(binding.Initializer)
+		}
+	}
+}
+
+func (w *jsWalker) walkExpression(expr ast.Expression) {
+	if w.found != nil || expr == nil {
+		return
+	}
+
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		if jsBannedIdentifiers[e.Name.String()] {
+			w.reject(e.Name.String(), "Identifier")
+		}
+	case *ast.CallExpression:
+		w.walkExpression(e.Callee)
+		for _, arg := range e.ArgumentList {
+			w.walkExpression(arg)
+		}
+	case *ast.NewExpression:
+		w.walkExpression(e.Callee)
+		for _, arg := range e.ArgumentList {
+			w.walkExpression(arg)
+		}
+	case *ast.DotExpression:
+		w.walkExpression(e.Left)
+	case *ast.BracketExpression:
+		w.walkExpression(e.Left)
+		w.walkExpression(e.Member)
+	case *ast.AssignExpression:
+		w.walkExpression(e.Left)
+		w.walkExpression(e.Right)
+	case *ast.BinaryExpression:
+		w.walkExpression(e.Left)
+		w.walkExpression(e.Right)
+	case *ast.UnaryExpression:
+		w.walkExpression(e.Operand)
+	case *ast.ConditionalExpression:
+		w.walkExpression(e.Test)
+		w.walkExpression(e.Consequent)
+		w.walkExpression(e.Alternate)
+	case *ast.SequenceExpression:
+		for _, inner := range e.Sequence {
+			w.walkExpression(inner)
+		}
+	case *ast.FunctionLiteral:
+		if e.Body != nil {
+			w.walkStatement(e.Body)
+		}
+	case *ast.ArrowFunctionLiteral:
+		w.walkConciseBody(e.Body)
+	}
+}
+
+// walkConciseBody walks an arrow function's body, which the parser represents as either a block
+// (`() => { ... }`) or a bare expression (`() => process`).
+func (w *jsWalker) walkConciseBody(body ast.ConciseBody) {
+	switch b := body.(type) {
+	case *ast.BlockStatement:
+		w.walkStatement(b)
+	case *ast.ExpressionBody:
+		w.walkExpression(b.Expression)
+	}
+}
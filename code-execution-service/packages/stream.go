@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/IMMZEK/AggieCode/code-execution-service/packages/lang"
+	"github.com/gorilla/websocket"
+)
+
+// streamUpgrader upgrades /api/execute/stream requests to a WebSocket. CheckOrigin is relaxed to
+// allow any origin, matching the rest of ExecutionService, which has no CORS policy configured
+// either - this is meant to be reached by the service's own clients, not embedded in arbitrary
+// third-party pages.
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// streamClientMessage is a message the client may send over the socket once a session is
+// running: either code to forward to the process's stdin, or a signal (currently only "kill") to
+// end the session early.
+type streamClientMessage struct {
+	Stdin  string `json:"stdin,omitempty"`
+	Signal string `json:"signal,omitempty"`
+}
+
+// streamServerFrame is a frame ExecutionService sends back: "output" frames carry one chunk of
+// stdout/stderr as it's produced, and a final "exit" frame reports how the process ended.
+type streamServerFrame struct {
+	Type   string `json:"type"`
+	Stream string `json:"stream,omitempty"`
+	Data   string `json:"data,omitempty"`
+	Code   int    `json:"code,omitempty"`
+	TimeMs int64  `json:"time_ms,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// streamableLanguages are the languages StreamSession currently knows how to run: interpreted
+// languages whose code runs directly as a single argv, same scoping WarmPool uses for warm
+// reuse. cpp and java need a compile-to-file step before the streamable process even starts,
+// which isn't wired up here yet.
+var streamableLanguages = map[string]func(opts lang.ExecOptions) []string{
+	"python": func(opts lang.ExecOptions) []string {
+		return append([]string{"python3", "-c", opts.Code}, opts.Args...)
+	},
+	"js": func(opts lang.ExecOptions) []string { return append([]string{"node", "-e", opts.Code}, opts.Args...) },
+	"go": func(opts lang.ExecOptions) []string { return append([]string{"go", "run", "main.go"}, opts.Args...) },
+}
+
+// HandleExecuteStream upgrades to a WebSocket at /api/execute/stream and streams stdout/stderr
+// frames from the underlying container process as they're produced, followed by a final
+// {"type":"exit"} frame. A WebSocket handshake request can't carry a JSON body the way a normal
+// POST can, so the client's first message after the upgrade is the ExecutionRequest itself;
+// every message after that is a streamClientMessage - {"stdin": "..."} is forwarded to the
+// process's stdin, and {"signal": "kill"} ends the session early.
+func (s *ExecutionService) HandleExecuteStream(w http.ResponseWriter, r *http.Request) {
+	conn, err := streamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("execute stream: upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	var req ExecutionRequest
+	if err := conn.ReadJSON(&req); err != nil {
+		conn.WriteJSON(streamServerFrame{Type: "exit", Error: ErrInvalidRequest.Error()})
+		return
+	}
+	if err := s.validateRequest(&req); err != nil {
+		conn.WriteJSON(streamServerFrame{Type: "exit", Error: err.Error()})
+		return
+	}
+
+	containerName := s.containers[req.Language]
+	if err := s.Sanitizer.SanitizeCode(req.Code, req.Language, containerName); err != nil {
+		conn.WriteJSON(streamServerFrame{Type: "exit", Error: err.Error()})
+		return
+	}
+
+	buildArgv, ok := streamableLanguages[req.Language]
+	if !ok {
+		conn.WriteJSON(streamServerFrame{Type: "exit", Error: "streaming is not yet supported for this language"})
+		return
+	}
+
+	opts := lang.ExecOptions{Code: req.Code, Stdin: req.Stdin, MemoryLimitMB: req.MemoryLimitMB, Args: req.Args}
+	session, err := lang.StartSession(r.Context(), containerName, "", buildArgv(opts), req.MemoryLimitMB)
+	if err != nil {
+		conn.WriteJSON(streamServerFrame{Type: "exit", Error: err.Error()})
+		return
+	}
+	defer session.Kill()
+
+	session.SetDeadline(time.Now().Add(clampTimeout(req.Timeout)))
+	if req.Stdin != "" {
+		session.Write(req.Stdin)
+	}
+
+	go readStreamClientMessages(conn, session)
+
+	for {
+		select {
+		case frame, ok := <-session.Frames:
+			if !ok {
+				continue
+			}
+			if err := conn.WriteJSON(streamServerFrame{Type: "output", Stream: frame.Stream, Data: frame.Data}); err != nil {
+				session.Kill()
+			}
+		case exit, ok := <-session.Exit:
+			if !ok {
+				return
+			}
+			conn.WriteJSON(streamServerFrame{Type: "exit", Code: exit.Code, TimeMs: exit.TimeMs, Error: exit.ErrorType})
+			return
+		}
+	}
+}
+
+// readStreamClientMessages drains client messages for the life of the socket, forwarding stdin
+// and honoring a kill signal. It returns once the client closes the connection or sends a
+// malformed frame, at which point the ReadJSON error ends the loop.
+func readStreamClientMessages(conn *websocket.Conn, session *lang.StreamSession) {
+	for {
+		var msg streamClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch {
+		case msg.Signal == "kill":
+			session.Kill()
+		case msg.Stdin != "":
+			session.Write(msg.Stdin)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestHandleExecuteStream(t *testing.T) {
+	service := NewExecutionService()
+	ts := httptest.NewServer(http.HandlerFunc(service.HandleExecuteStream))
+	defer ts.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+	req := ExecutionRequest{Language: "python", Code: "print('hello')", Method: "run"}
+	body, _ := json.Marshal(req)
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, body); err != nil {
+		t.Fatalf("write request failed: %v", err)
+	}
+
+	var sawExit bool
+	for !sawExit {
+		var frame streamServerFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			t.Fatalf("read frame failed: %v", err)
+		}
+		if frame.Type == "exit" {
+			sawExit = true
+			if frame.Error != "" {
+				t.Errorf("exit frame reported error: %q", frame.Error)
+			}
+		}
+	}
+}
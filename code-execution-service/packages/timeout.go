@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultTimeoutMargin is how long before the server's WriteTimeout TimeoutMiddleware preempts
+// it with a synthetic response, so the write actually has time to reach the client before the
+// connection is torn down.
+const defaultTimeoutMargin = 500 * time.Millisecond
+
+// TimeoutMiddleware wraps next (normally HandleExecute) the way http.TimeoutHandler wraps a
+// handler, but - unlike http.TimeoutHandler - it's JSON-aware: on a timeout it writes a
+// fully-formed ExecutionResponse{ErrorType: ErrorTypeTimeout} instead of http.TimeoutHandler's
+// plain-text body, and it cancels the request context so the executor's docker exec is killed
+// instead of left running after the client has already gotten a response.
+//
+// writeTimeout should match the http.Server's own WriteTimeout; margin (<= 0 defaults to
+// defaultTimeoutMargin) is how long before that deadline this middleware preempts it - the
+// server's own timeout firing mid-write can't produce a valid body, so this has to win the race.
+//
+// Apply this middleware directly around next, beneath any gzip/compression middleware: once
+// WriteTimeout fires there's no chance to flush a chunked terminating chunk or a gzip trailer, so
+// the synthetic response is written with an explicit Content-Length and no Content-Encoding.
+func TimeoutMiddleware(next http.Handler, writeTimeout, margin time.Duration) http.Handler {
+	if margin <= 0 {
+		margin = defaultTimeoutMargin
+	}
+	preemptAfter := writeTimeout - margin
+	if preemptAfter <= 0 {
+		preemptAfter = writeTimeout
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		tw := newTimeoutWriter(w)
+		done := make(chan struct{})
+		go func() {
+			next.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		timer := time.NewTimer(preemptAfter)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+			tw.finish()
+		case <-timer.C:
+			cancel() // let the executor's docker exec be killed instead of leaking
+			tw.timeoutNow()
+		}
+	})
+}
+
+// timeoutWriter buffers next's header and body instead of writing them straight through, so that
+// if the write-timeout margin fires while next is still running, its eventual (or in-flight)
+// writes can be discarded instead of racing with the synthetic timeout response on the real
+// http.ResponseWriter.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	w        http.ResponseWriter
+	h        http.Header
+	body     bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func newTimeoutWriter(w http.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{w: w, h: make(http.Header), code: http.StatusOK}
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.h }
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return len(p), nil
+	}
+	return tw.body.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.code = code
+}
+
+// finish copies the buffered header and body to the real ResponseWriter. It's a no-op if
+// timeoutNow already fired first.
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	dst := tw.w.Header()
+	for k, v := range tw.h {
+		dst[k] = v
+	}
+	tw.w.WriteHeader(tw.code)
+	tw.w.Write(tw.body.Bytes())
+}
+
+// timeoutNow switches tw into timed-out mode - discarding anything next writes to it from here
+// on - and writes a synthetic ExecutionResponse directly to the real ResponseWriter.
+func (tw *timeoutWriter) timeoutNow() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.timedOut = true
+	writeTimeoutResponse(tw.w)
+}
+
+// writeTimeoutResponse writes a well-formed ExecutionResponse straight to w, with an explicit
+// Content-Length and no Content-Encoding/Transfer-Encoding: once the server's WriteTimeout has
+// nearly fired there's no time left to flush a chunked terminating chunk or a gzip trailer, so
+// the body has to be short, uncompressed, and fully length-prefixed up front.
+func writeTimeoutResponse(w http.ResponseWriter) {
+	body, err := json.Marshal(ExecutionResponse{
+		Error:         "execution exceeded the server's write timeout",
+		ErrorType:     ErrorTypeTimeout,
+		StatusMessage: "Timeout",
+	})
+	if err != nil {
+		return
+	}
+
+	h := w.Header()
+	h.Del("Transfer-Encoding")
+	h.Del("Content-Encoding")
+	h.Set("Content-Type", "application/json")
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusGatewayTimeout)
+	w.Write(body)
+}